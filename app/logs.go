@@ -0,0 +1,194 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/bingoohuang/bssh/logs"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/bingoohuang/ngg/ver"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+)
+
+// Llogs lists, searches and shows the terminal logs.
+func Llogs() (app *cli.App) {
+	app = cli.NewApp()
+	app.Name = "bssh logs"
+	app.Usage = "list, search and show the terminal logs of [log] dirpath."
+	app.Copyright = misc.Copyright
+	app.Version = ver.Version()
+
+	filterFlags := []cli.Flag{
+		cli.StringFlag{Name: "server,s", Usage: "log of `servername`, glob pattern like web* is allowed"},
+		cli.StringFlag{Name: "since", Usage: "log written since `time`, like 30m, 12h, 2d, 1w or 2006-01-02"},
+		cli.StringFlag{Name: "run", Usage: "log of the cmd or pshell run `id`, like 150405-ab3d"},
+	}
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name: "cnf,c", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
+			Usage: "config file path",
+		},
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name: "list", Aliases: []string{"ls"}, Usage: "list the log files",
+			Flags: filterFlags, Action: logsListAction,
+		},
+		{
+			Name: "grep", Usage: "search the log files by the regexp", ArgsUsage: "pattern",
+			Flags:  append([]cli.Flag{cli.BoolFlag{Name: "ignore-case,i", Usage: "ignore case"}}, filterFlags...),
+			Action: logsGrepAction,
+		},
+		{
+			Name: "show", Usage: "print the log file, or the latest log of the server", ArgsUsage: "file|servername",
+			Action: logsShowAction,
+		},
+	}
+
+	return app
+}
+
+func logsDir(c *cli.Context) string {
+	return conf.ReadConf(c.GlobalString("cnf")).Log.Dir
+}
+
+func findLogs(c *cli.Context) ([]logs.Entry, error) {
+	since, err := logs.ParseSince(c.String("since"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return logs.Find(logsDir(c), logs.Filter{Server: c.String("server"), Since: since, Run: c.String("run")})
+}
+
+func logsListAction(c *cli.Context) error {
+	entries, err := findLogs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	logs.PrintList(os.Stdout, entries)
+
+	return nil
+}
+
+func logsGrepAction(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return cli.NewExitError("bssh logs grep: pattern is required", 1)
+	}
+
+	pattern := c.Args().First()
+	if c.Bool("ignore-case") {
+		pattern = "(?i)" + pattern
+	}
+
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	entries, err := findLogs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	found, err := logs.Grep(os.Stdout, entries, reg)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if !found {
+		return cli.NewExitError("", 1)
+	}
+
+	return nil
+}
+
+func logsShowAction(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return cli.NewExitError("bssh logs show: file or servername is required", 1)
+	}
+
+	e, err := logs.Resolve(logsDir(c), c.Args().First())
+	if err == nil {
+		err = logs.Show(os.Stdout, e)
+	}
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	return nil
+}
+
+// Lreplay plays the recorded terminal logs.
+func Lreplay() (app *cli.App) {
+	app = cli.NewApp()
+	app.Name = "bssh replay"
+	app.Usage = "play the terminal log (asciicast, or text log with timestamp) back in real time."
+	app.UsageText = "bssh replay [options] file|servername\n\n" +
+		"   keys: space pause/resume, +/- speed up/down, →/l seek forward 5s, ←/h seek backward 5s, q quit"
+	app.Copyright = misc.Copyright
+	app.Version = ver.Version()
+	app.Flags = []cli.Flag{
+		cli.Float64Flag{Name: "speed,s", Value: 1, Usage: "playback `speed`, 2 plays twice as fast"},
+		cli.Float64Flag{Name: "idle,i", Usage: "limit the idle time between outputs to `seconds`, 0 is unlimited"},
+		cli.StringFlag{
+			Name: "cnf,c", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
+			Usage: "config file path",
+		},
+		cli.BoolFlag{Name: "help,h", Usage: "print this help"},
+	}
+
+	app.HideHelp = true
+	app.Action = replayAction
+
+	return app
+}
+
+func replayAction(c *cli.Context) error {
+	common.CheckHelpFlag(c)
+
+	if c.NArg() == 0 {
+		return cli.NewExitError("bssh replay: file or servername is required", 1)
+	}
+
+	arg := c.Args().First()
+	dir := ""
+	if _, err := os.Stat(arg); err != nil {
+		dir = conf.ReadConf(c.String("cnf")).Log.Dir
+	}
+
+	e, err := logs.Resolve(dir, arg)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	frames, err := logs.LoadFrames(e.Path)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	p := &logs.Player{Frames: frames, Out: os.Stdout, Speed: c.Float64("speed"), Idle: c.Float64("idle")}
+
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err == nil {
+			defer term.Restore(fd, state)
+			p.Keys = logs.ReadKeys(os.Stdin)
+		}
+	}
+
+	p.Play()
+	fmt.Print("\r\n")
+
+	return nil
+}
@@ -43,8 +43,20 @@ USAGE:
     # remote to local scp
     {{.Name}} remote:/path/to/remote... /path/to/local
 
-    # remote to remote scp
+    # remote to remote scp, per source subdirectory if multiple sources
     {{.Name}} remote:/path/to/remote... remote:/path/to/local
+
+    # remote to remote scp, the sources ssh to the targets directly, not through local
+    {{.Name}} --direct remote:/path/to/remote... remote:/path/to/local
+
+    # continue the interrupted transfer, and verify by sha256 after transfer
+    {{.Name}} --resume --verify sha256 /path/to/local... remote:/path/to/remote
+
+    # stream the many small files as a gzip compressed tar archive
+    {{.Name}} --tar --compress gzip /path/to/local... remote:/path/to/remote
+
+    # push to many hosts with a progress row per host and the summary
+    {{.Name}} --aggregate /path/to/local... remote:/path/to/remote
 `
 
 // Lscp scp ...
@@ -63,8 +75,15 @@ func Lscp() (app *cli.App) {
 			Name: "cnf,c", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
 			Usage: "config file path",
 		},
+		cli.BoolFlag{Name: "resume", Usage: "continue from the existing destination files, if their prefixes are identical"},
+		cli.StringFlag{Name: "verify", Usage: "verify the files after transfer by `sha256|md5`"},
+		cli.BoolFlag{Name: "tar", Usage: "stream the files as a tar archive over an exec session, fall back to sftp without tar"},
+		cli.StringFlag{Name: "compress", Usage: "compress the tar stream by `zstd|gzip`"},
+		cli.BoolFlag{Name: "direct", Usage: "stream from the source hosts straight to the targets by ssh with agent forwarding, in remote to remote copy"},
+		cli.BoolFlag{Name: "aggregate", Usage: "show a row per host, files done/total, bytes, speed, ETA and errors, instead of a bar per file"},
 		cli.BoolFlag{Name: "help,h", Usage: "print this help"},
 	}
+	app.Flags = append(app.Flags, common.FilterFlags(true)...)
 	app.EnableBashCompletion = true
 	app.HideHelp = true
 	app.Action = lscpAction
@@ -85,6 +104,24 @@ func lscpAction(c *cli.Context) error {
 		os.Exit(1)
 	}
 
+	verify, err := common.ParseVerify(c.String("verify"))
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	filter, err := common.ParseFilter(c)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	compress, err := scp.ParseCompress(c.String("compress"))
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// Set args path
 	fromArgs, toArg := args[:nargs-1], args[nargs-1]
 	isFromInRemote, isFromInLocal := parseFromLocation(fromArgs)
@@ -101,6 +138,19 @@ func lscpAction(c *cli.Context) error {
 	// Check from and to Type
 	check.TypeError(isFromInRemote, isFromInLocal, isToRemote, len(hosts))
 
+	if c.Bool("direct") && !(isFromInRemote && isToRemote) {
+		_, _ = fmt.Fprintln(os.Stderr, "--direct is only for REMOTE to REMOTE copy.")
+		os.Exit(1)
+	}
+
+	// the direct mode streams by tar and ssh on the source hosts, nothing passes through local to filter or verify.
+	for _, name := range []string{"resume", "verify", "exclude", "include", "exclude-from", "gitignore"} {
+		if c.Bool("direct") && c.IsSet(name) {
+			_, _ = fmt.Fprintf(os.Stderr, "--direct does not support --%s.\n", name)
+			os.Exit(1)
+		}
+	}
+
 	toServer, fromServer := parseFromToServer(hosts, names, isFromInRemote, isToRemote, data)
 
 	// scpService struct
@@ -122,6 +172,12 @@ func lscpAction(c *cli.Context) error {
 	scpService.To.Server = toServer
 
 	scpService.Config = data
+	scpService.Option = common.TransferOption{Resume: c.Bool("resume"), Verify: verify}
+	scpService.Filter = filter
+	scpService.Tar = c.Bool("tar") || compress != ""
+	scpService.Compress = compress
+	scpService.Direct = c.Bool("direct")
+	scpService.Aggregate = c.Bool("aggregate")
 
 	printFromTo(isFromInRemote, scpService, isToRemote)
 
@@ -162,7 +218,7 @@ func parseFromToServer(hosts, names []string, isFromInRemote, isToRemote bool, d
 		}
 	// remote to remote scp
 	case isFromInRemote && isToRemote:
-		fromServer = list.ShowServersView(&data, "bssh scp(from)>>", names, false)
+		fromServer = list.ShowServersView(&data, "bssh scp(from)>>", names, true)
 		toServer = list.ShowServersView(&data, "bssh scp(to)>>", names, true)
 	default:
 		selected = list.ShowServersView(&data, "bssh scp>>", names, true)
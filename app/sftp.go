@@ -1,6 +1,8 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 
@@ -36,6 +38,12 @@ VERSION:
 USAGE:
 	# start lsftp shell
 	{{.Name}}
+
+	# run the commands non-interactively, stop on the first error unless the command is prefixed by -
+	{{.Name}} -c "cd /opt/app; put app.tar.gz; -rm app.tar.gz.bak"
+
+	# run the commands of the batch file, one per line, - for stdin
+	{{.Name}} -H web01,web02 -b deploy.txt
 `
 
 // Lsftp sftp ...
@@ -51,9 +59,11 @@ func Lsftp() (app *cli.App) {
 	app.Flags = []cli.Flag{
 		cli.StringSliceFlag{Name: "host,H", Usage: "connect `servername`.", Value: &envHosts},
 		cli.StringFlag{
-			Name: "cnf,c", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
+			Name: "cnf", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
 			Usage: "config file path",
 		},
+		cli.StringFlag{Name: "batch,b", Usage: "run the commands of the `batchfile` non-interactively, - for stdin"},
+		cli.StringFlag{Name: "command,c", Usage: "run the `commands` separated by ; non-interactively"},
 		cli.BoolFlag{Name: "help,h", Usage: "print this help"},
 	}
 
@@ -82,7 +92,33 @@ func lsftpAction(c *cli.Context) error {
 	r.Config = data
 	r.SelectServer = parseSelected("bssh ftp>>", hosts, names, data, true)
 
+	if batch, command := c.String("batch"), c.String("command"); batch != "" || command != "" {
+		if err := runBatch(r, confpath, batch, command); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return nil
+	}
+
 	r.Start(confpath)
 
 	return nil
 }
+
+// runBatch runs the commands of -b batchfile or -c commands.
+func runBatch(r *sftp.RunSftp, confpath, batch, command string) error {
+	if batch != "" && command != "" {
+		return errors.New("-b and -c can not be used together")
+	}
+
+	commands := sftp.SplitCommands(command)
+	if batch != "" {
+		var err error
+		if commands, err = sftp.ReadBatch(batch); err != nil {
+			return err
+		}
+	}
+
+	return r.StartBatch(confpath, commands)
+}
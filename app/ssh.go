@@ -92,6 +92,7 @@ func Lssh() (app *cli.App) {
 		// Other bool
 		cli.BoolFlag{Name: "w", Usage: "Displays the server header when in command execution mode."},
 		cli.BoolFlag{Name: "W", Usage: "Not displays the server header when in command execution mode."},
+		cli.BoolFlag{Name: "collapse", Usage: "print the identical outputs of hosts once, headed by the host list like web[01-03]."},
 		cli.BoolFlag{Name: "not-execute,N", Usage: "not execute remote command and shell."},
 		cli.BoolFlag{Name: "x11,X", Usage: "x11 forwarding(forward to ${DISPLAY})."},
 		cli.BoolFlag{Name: "term,t", Usage: "run specified command at terminal."},
@@ -147,6 +148,8 @@ func lsshAction(c *cli.Context) error {
 		r.DisableHeader = true
 	}
 
+	r.Collapse = c.Bool("collapse")
+
 	if err := dealPortForward(c, r); err != nil {
 		fmt.Printf("Error: %s \n", err)
 	}
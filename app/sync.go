@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bingoohuang/bssh/check"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/bssh/scp"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/bingoohuang/ngg/ver"
+	"github.com/urfave/cli"
+)
+
+// nolint
+const lsyncAppHelpTemplate = `NAME:
+    {{.Name}} - {{.Usage}}
+USAGE:
+    {{.HelpName}} {{if .VisibleFlags}}[options]{{end}} (local|remote|servername):from_dir (local|remote|servername):to_dir
+    {{if .VisibleFlags}}
+OPTIONS:
+    {{range .VisibleFlags}}{{.}}
+    {{end}}{{end}}{{if .Version}}
+VERSION:
+    {{.Version}}
+    {{end}}
+USAGE:
+    # sync the contents of local dir to the remote dir of web01 and web02
+    {{.Name}} /path/to/local web01,web02:/path/to/remote
+
+    # sync the remote dir of the selected servers to the local dir, per server subdirectory if multiple
+    {{.Name}} r:/path/to/remote /path/to/local
+
+    # list the changes only, delete the extra remote files, skip the logs
+    {{.Name}} -n --delete --exclude '*.log' /path/to/local r:/path/to/remote
+`
+
+// Lsync syncs the directory incrementally between local and the remote servers.
+func Lsync() (app *cli.App) {
+	cli.AppHelpTemplate = lsyncAppHelpTemplate
+	app = cli.NewApp()
+	app.Name = "bssh sync"
+	app.Usage = "TUI list select and parallel rsync-style incremental directory sync."
+	app.Copyright = misc.Copyright
+	app.Version = ver.Version()
+
+	envHosts := cli.StringSlice(strings.Split(os.Getenv("HOST"), ","))
+	app.Flags = []cli.Flag{
+		cli.StringSliceFlag{Name: "host,H", Usage: "connect server names", Value: &envHosts},
+		cli.StringFlag{
+			Name: "cnf,c", Value: ss.ExpandHome("~/.bssh/.bssh.toml"),
+			Usage: "config file path",
+		},
+		cli.BoolFlag{Name: "delete", Usage: "delete the destination files not in the source"},
+		cli.BoolFlag{Name: "dry-run,n", Usage: "print the changes only"},
+		cli.BoolFlag{Name: "checksum", Usage: "compare the files of the same size and mtime by sha256"},
+		cli.BoolFlag{Name: "aggregate", Usage: "show a row per host, files done/total, bytes, speed, ETA and errors, instead of a bar per file"},
+		cli.BoolFlag{Name: "help,h", Usage: "print this help"},
+	}
+	app.Flags = append(app.Flags, common.FilterFlags(false)...)
+	app.EnableBashCompletion = true
+	app.HideHelp = true
+	app.Action = lsyncAction
+
+	return app
+}
+
+func lsyncAction(c *cli.Context) error {
+	common.CheckHelpFlag(c)
+
+	args, argOptions := conf.ParseMoreOptions(c.Args())
+	if len(args) != 2 {
+		_, _ = fmt.Fprintln(os.Stderr, "Requires two arguments, from_dir and to_dir.")
+		_ = cli.ShowAppHelp(c)
+
+		os.Exit(1)
+	}
+
+	isFromRemote, fromHosts, fromPath := parseSyncPath(args[0])
+	isToRemote, toHosts, toPath := parseSyncPath(args[1])
+
+	if isFromRemote == isToRemote {
+		_, _ = fmt.Fprintln(os.Stderr, "One of from_dir and to_dir should be LOCAL, the other REMOTE.")
+		os.Exit(1)
+	}
+
+	if op := argOptions.FindByName("host"); op != nil {
+		op.Values = append(op.Values, fromHosts...)
+		op.Values = append(op.Values, toHosts...)
+	}
+
+	confpath := c.String("cnf")
+	data := conf.ReadConf(confpath)
+	names := data.GetNameSortedList()
+
+	hosts, searchNames := data.ExpandHosts(c, &argOptions)
+	if searchNames != nil {
+		names = searchNames
+	}
+
+	servers := parseSelected("bssh sync>>", hosts, names, data, true)
+
+	cp := new(scp.Scp)
+	cp.Config = data
+	cp.Aggregate = c.Bool("aggregate")
+	cp.From = scp.Info{IsRemote: isFromRemote, Path: []string{fromPath}}
+	cp.To = scp.Info{IsRemote: isToRemote, Path: []string{toPath}}
+
+	if isFromRemote {
+		cp.From.Server = servers
+	} else {
+		cp.To.Server = servers
+	}
+
+	filter, err := common.ParseFilter(c)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// sync mirrors the hidden files as well.
+	filter.Hidden = true
+
+	printFromTo(isFromRemote, cp, isToRemote)
+
+	cp.StartSync(confpath, scp.SyncOption{
+		Delete:   c.Bool("delete"),
+		DryRun:   c.Bool("dry-run"),
+		Checksum: c.Bool("checksum"),
+		Filter:   *filter,
+	})
+
+	return nil
+}
+
+// parseSyncPath parses the path of bssh sync, the prefix is local, remote as scp,
+// or the comma separated server names, like web01,web02:/path.
+func parseSyncPath(arg string) (isRemote bool, hosts []string, p string) {
+	prefix, rest, ok := strings.Cut(arg, ":")
+	if !ok {
+		return false, nil, common.GetFullPath(arg)
+	}
+
+	switch strings.ToLower(prefix) {
+	case "local", "l":
+		return false, nil, common.GetFullPath(rest)
+	case "remote", "r":
+		isRemote, p = check.ParseScpPath(arg)
+		return isRemote, nil, p
+	}
+
+	isRemote, p = check.ParseScpPath("r:" + rest)
+
+	return isRemote, strings.Split(prefix, ","), p
+}
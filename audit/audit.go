@@ -0,0 +1,193 @@
+// Package audit records the executed actions, who ran what, where and when, as JSON lines to the sinks.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions of the audit records.
+const (
+	ActionShell    = "shell"    // interactive connection
+	ActionCmd      = "cmd"      // command run on the servers
+	ActionPshell   = "pshell"   // command in parallel shell
+	ActionScpPut   = "scp.put"  // scp local to remote
+	ActionScpGet   = "scp.get"  // scp remote to local
+	ActionScpCopy  = "scp.copy" // scp remote to remote
+	ActionSftpPut  = "sftp.put"
+	ActionSftpGet  = "sftp.get"
+	ActionUpload   = "upload"   // .up in shell
+	ActionDownload = "download" // .dl in shell
+	ActionForward  = "forward"  // port forwarding opened
+	ActionEdit     = "edit"     // remote file edited by edit in sftp or .edit in shell
+)
+
+// Record is an audit record, written as a JSON line.
+type Record struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Hostname string    `json:"hostname"`
+	Action   string    `json:"action"`
+	Servers  []string  `json:"servers,omitempty"`
+	RunID    string    `json:"runId,omitempty"`
+	Command  string    `json:"command,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Target   string    `json:"target,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	// Failed is the servers that the command failed on.
+	Failed []string `json:"failed,omitempty"`
+	// Status is the exit status, 0 is success, -1 is failed without exit status.
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Sink writes the audit records.
+type Sink interface {
+	Write(line []byte) error
+}
+
+var (
+	mu          sync.Mutex
+	sinks       []Sink
+	redact      = func(s string) string { return s }
+	localUsr    = localUser()
+	hostname, _ = os.Hostname()
+)
+
+// Setup sets the sinks, like "file", "syslog" or "http://127.0.0.1:8080/audit".
+// The file sink writes to dir, and redactFn masks the secrets in the commands and paths, nil for none.
+func Setup(dir string, sinkNames []string, redactFn func(string) string) error {
+	var created []Sink
+	var errs []error
+
+	if len(sinkNames) == 0 {
+		sinkNames = []string{"file"}
+	}
+
+	for _, name := range sinkNames {
+		switch {
+		case name == "file":
+			created = append(created, &FileSink{Dir: dir})
+		case name == "syslog":
+			s, err := NewSyslogSink()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			created = append(created, s)
+		case strings.HasPrefix(name, "http://"), strings.HasPrefix(name, "https://"):
+			created = append(created, &HTTPSink{URL: name})
+		default:
+			errs = append(errs, fmt.Errorf("unknown audit sink %q", name))
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	sinks = created
+	if redactFn != nil {
+		redact = redactFn
+	}
+
+	return errors.Join(errs...)
+}
+
+// Enabled reports whether any sink is set.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return len(sinks) > 0
+}
+
+// Log writes the record to the sinks, the time, user and hostname are filled if empty.
+// The sinks are written outside the lock, so a slow sink, like http, does not block the other records.
+func Log(r Record) {
+	mu.Lock()
+	sinks, redact := sinks, redact
+	mu.Unlock()
+
+	if len(sinks) == 0 {
+		return
+	}
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	if r.User == "" {
+		r.User = localUsr
+	}
+
+	if r.Hostname == "" {
+		r.Hostname = hostname
+	}
+
+	r.Command, r.Source, r.Target = redact(r.Command), redact(r.Source), redact(r.Target)
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("audit: marshal record: %v", err)
+		return
+	}
+
+	for _, s := range sinks {
+		if err := s.Write(append(line, '\n')); err != nil {
+			log.Printf("audit: %v", err)
+		}
+	}
+}
+
+// Event is an action in progress, logged when it ends.
+type Event struct {
+	Record
+	start time.Time
+}
+
+// Start starts an event of action on the servers.
+func Start(action string, servers ...string) *Event {
+	return &Event{Record: Record{Action: action, Servers: servers}, start: time.Now()}
+}
+
+// End logs the event with the exit status of err.
+func (e *Event) End(err error) {
+	e.Time = e.start
+	e.Duration = time.Since(e.start).Round(time.Millisecond).String()
+	e.Status = ExitStatus(err)
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	Log(e.Record)
+}
+
+// ExitStatus returns the exit status of err, 0 for nil, and -1 if err has no exit status.
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr interface{ ExitStatus() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus()
+	}
+
+	return -1
+}
+
+func localUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exitError int
+
+func (e exitError) Error() string   { return "exit status" }
+func (e exitError) ExitStatus() int { return int(e) }
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, Setup(dir, nil, func(s string) string { return strings.ReplaceAll(s, "s3cret", "******") }))
+	defer func() { sinks = nil }()
+
+	assert.True(t, Enabled())
+	assert.NotNil(t, Setup(dir, []string{"file", "ftp://x"}, nil))
+
+	e := Start(ActionCmd, "web1", "web2")
+	e.Command, e.Failed = "echo s3cret", []string{"web2"}
+	e.End(errors.Join(errors.New("web2"), exitError(2)))
+
+	e = Start(ActionScpPut, "web1")
+	e.Source, e.Target, e.Size = "a.txt", "web1:/tmp/a.txt", 42
+	e.End(nil)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	assert.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	assert.Nil(t, err)
+	defer f.Close()
+
+	var records []Record
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var r Record
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+
+	assert.Len(t, records, 2)
+	assert.Equal(t, "echo ******", records[0].Command)
+	assert.Equal(t, 2, records[0].Status)
+	assert.Equal(t, []string{"web1", "web2"}, records[0].Servers)
+	assert.Equal(t, []string{"web2"}, records[0].Failed)
+	assert.NotEmpty(t, records[0].User)
+	assert.Equal(t, int64(42), records[1].Size)
+	assert.Equal(t, 0, records[1].Status)
+	assert.Equal(t, -1, ExitStatus(errors.New("x")))
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSink appends the records to the daily JSONL files in Dir, like audit-20240102.jsonl.
+type FileSink struct {
+	Dir string
+}
+
+func (s *FileSink) Write(line []byte) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+
+	file := filepath.Join(s.Dir, "audit-"+time.Now().Format("20060102")+".jsonl")
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// HTTPSink posts each record to URL as application/x-ndjson.
+type HTTPSink struct {
+	URL string
+}
+
+var httpClient = &http.Client{Timeout: 3 * time.Second}
+
+func (s *HTTPSink) Write(line []byte) error {
+	rsp, err := httpClient.Post(s.URL, "application/x-ndjson", bytes.NewReader(line))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("post %s: %s", s.URL, rsp.Status)
+	}
+
+	return nil
+}
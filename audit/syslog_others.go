@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package audit
+
+import "errors"
+
+// NewSyslogSink is not supported on this platform.
+func NewSyslogSink() (Sink, error) {
+	return nil, errors.New("syslog audit sink is not supported on this platform")
+}
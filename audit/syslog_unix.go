@@ -0,0 +1,24 @@
+//go:build !windows && !plan9
+
+package audit
+
+import "log/syslog"
+
+// syslogSink writes the records to the local syslog with tag bssh.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects the local syslog.
+func NewSyslogSink() (Sink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, "bssh")
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(line []byte) error {
+	return s.w.Info(string(line))
+}
@@ -36,14 +36,26 @@ func main() {
 			args = append(os.Args[0:1], os.Args[1:i]...)
 			args = append(args, flagSet.Args()[1:]...)
 			ap = app.Lscp()
-		case "ftp":
+		case "sync":
 			args = append(os.Args[0:1], os.Args[1:i]...)
 			args = append(args, flagSet.Args()[1:]...)
+			ap = app.Lsync()
+		case "ftp":
+			args = append(os.Args[0:1], ftpArgs(os.Args[1:i])...)
+			args = append(args, flagSet.Args()[1:]...)
 			ap = app.Lsftp()
 		case misc.SSH:
 			args = append(os.Args[0:1], os.Args[1:i]...)
 			args = append(args, flagSet.Args()[1:]...)
 			ap = app.Lssh()
+		case "logs":
+			args = append(os.Args[0:1], os.Args[1:i]...)
+			args = append(args, flagSet.Args()[1:]...)
+			ap = app.Llogs()
+		case "replay":
+			args = append(os.Args[0:1], os.Args[1:i]...)
+			args = append(args, flagSet.Args()[1:]...)
+			ap = app.Lreplay()
 		}
 	}
 
@@ -53,3 +65,24 @@ func main() {
 
 	_ = ap.Run(common.ParseArgs(ap.Flags, args))
 }
+
+// ftpArgs rewrites the config path -c before the ftp subcommand to --cnf,
+// because -c of bssh ftp is the batch commands.
+func ftpArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		switch {
+		case arg == "-c":
+			arg = "--cnf"
+		case strings.HasPrefix(arg, "-c="):
+			arg = "--cnf=" + arg[len("-c="):]
+		case strings.HasPrefix(arg, "-c"):
+			arg = "--cnf=" + arg[len("-c"):]
+		}
+
+		out = append(out, arg)
+	}
+
+	return out
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFtpArgs(t *testing.T) {
+	assert.Equal(t, []string{"--cnf", "x.toml", "-H", "web01"}, ftpArgs([]string{"-c", "x.toml", "-H", "web01"}))
+	assert.Equal(t, []string{"--cnf=x.toml"}, ftpArgs([]string{"-c=x.toml"}))
+	assert.Equal(t, []string{"--cnf=x.toml"}, ftpArgs([]string{"-cx.toml"}))
+	assert.Equal(t, []string{"--cnf", "x.toml"}, ftpArgs([]string{"--cnf", "x.toml"}))
+	assert.Empty(t, ftpArgs(nil))
+}
@@ -239,28 +239,9 @@ func GetUniqueSlice(data []string) (result []string) {
 	return
 }
 
-// WalkDir return file path list ([]string).
+// WalkDir return file path list ([]string), the hidden files are skipped.
 func WalkDir(dir string) (files []string, err error) {
-	_, err = os.Lstat(dir)
-	if err != nil {
-		return
-	}
-
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if IsHidden(dir, path) {
-			return nil // ignore hidden filesß
-		}
-
-		if info.IsDir() {
-			path += "/"
-		}
-
-		files = append(files, path)
-
-		return nil
-	})
-
-	return
+	return WalkDirFilter(dir, &Filter{})
 }
 
 // IsHidden tells a path is hidden after basedir.
@@ -428,3 +409,17 @@ parseloop:
 
 	return result
 }
+
+// ShellQuote quotes s for the POSIX shell, the leading ~/ (or ~ alone) is kept to be expanded by the shell.
+func ShellQuote(s string) string {
+	if s == "~" {
+		return s
+	}
+
+	prefix := ""
+	if strings.HasPrefix(s, "~/") {
+		prefix, s = "~/", s[2:]
+	}
+
+	return prefix + "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -160,3 +160,11 @@ func TestGetMaxLength(t *testing.T) {
 		assert.Equal(t, v.expect, got, v.desc)
 	}
 }
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'/tmp/a b'`, common.ShellQuote("/tmp/a b"))
+	assert.Equal(t, `'it'\''s'`, common.ShellQuote("it's"))
+	assert.Equal(t, `~/'a b'`, common.ShellQuote("~/a b"))
+	assert.Equal(t, `~`, common.ShellQuote("~"))
+	assert.Equal(t, `'~bob'`, common.ShellQuote("~bob"))
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Editor returns the local editor command, by $VISUAL, $EDITOR or vi.
+func Editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			return e
+		}
+	}
+
+	return "vi"
+}
+
+// Edit opens data in the local editor by a temp file named after name, returns the temp file and the edited data,
+// changed is false if not modified. The caller removes the temp file.
+func Edit(name string, data []byte) (tmp string, edited []byte, changed bool, err error) {
+	f, err := os.CreateTemp("", "bssh-edit-*-"+filepath.Base(name))
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	tmp = f.Name()
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+
+	if err != nil {
+		return tmp, nil, false, err
+	}
+
+	editor := strings.Fields(Editor())
+	if len(editor) == 0 {
+		return tmp, nil, false, errors.New("no editor")
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], tmp)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return tmp, nil, false, err
+	}
+
+	if edited, err = os.ReadFile(tmp); err != nil {
+		return tmp, nil, false, err
+	}
+
+	return tmp, edited, !bytes.Equal(data, edited), nil
+}
+
+// EditRemote reads the remote file by read, opens it in the local editor, and writes it back by write only if changed,
+// saved is false if not changed. The remote file changed in the meantime is not overwritten,
+// the edited temp file is kept then.
+func EditRemote(name string, read func() ([]byte, error), write func([]byte) error) (saved bool, err error) {
+	data, err := read()
+	if err != nil {
+		return false, err
+	}
+
+	tmp, edited, changed, err := Edit(name, data)
+	if err != nil || !changed {
+		os.Remove(tmp)
+		return false, err
+	}
+
+	current, err := read()
+	if err != nil {
+		return false, fmt.Errorf("%w, the edited file is kept at %s", err, tmp)
+	}
+
+	if !bytes.Equal(current, data) {
+		return false, fmt.Errorf("changed on remote since opened, not saved, the edited file is kept at %s", tmp)
+	}
+
+	if err := write(edited); err != nil {
+		return false, fmt.Errorf("%w, the edited file is kept at %s", err, tmp)
+	}
+
+	os.Remove(tmp)
+
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package common_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdit(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i s/80/8080/")
+
+	tmp, edited, changed, err := common.Edit("/etc/nginx/nginx.conf", []byte("listen 80;\n"))
+	defer os.Remove(tmp)
+
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "listen 8080;\n", string(edited))
+	assert.Contains(t, tmp, "nginx.conf")
+
+	t.Setenv("EDITOR", "true")
+
+	tmp, edited, changed, err = common.Edit("a.txt", []byte("a"))
+	defer os.Remove(tmp)
+
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "a", string(edited))
+}
+
+func TestEditRemote(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i s/80/8080/")
+
+	remote := "listen 80;\n"
+	read := func() ([]byte, error) { return []byte(remote), nil }
+	write := func(data []byte) error { remote = string(data); return nil }
+
+	saved, err := common.EditRemote("nginx.conf", read, write)
+	assert.Nil(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, "listen 8080;\n", remote)
+
+	// changed on remote since opened
+	reads := 0
+	changing := func() ([]byte, error) { reads++; return []byte(remote + strings.Repeat("#", reads)), nil }
+
+	saved, err = common.EditRemote("nginx.conf", changing, write)
+	assert.NotNil(t, err)
+	assert.False(t, saved)
+	assert.Equal(t, "listen 8080;\n", remote)
+
+	t.Setenv("EDITOR", "true")
+
+	saved, err = common.EditRemote("nginx.conf", read, func([]byte) error { t.Fatal("not changed, no write"); return nil })
+	assert.Nil(t, err)
+	assert.False(t, saved)
+}
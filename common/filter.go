@@ -0,0 +1,288 @@
+package common
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/urfave/cli"
+)
+
+// Filter is the include and exclude patterns of the transferred files, in gitignore syntax.
+// A pattern without / matches the name at any level, like *.log or node_modules,
+// a pattern with / matches the relative path from the transfer root, like build/*.tmp or /dist,
+// a pattern ending with / matches directories only, ** matches any directories, and ! negates.
+// The later patterns take precedence, the included files are never excluded.
+type Filter struct {
+	Include []string
+	Exclude []string
+
+	// Hidden includes the hidden files, whose names start with a dot.
+	Hidden bool
+
+	// GitIgnore honours the .gitignore files in the local source directories.
+	GitIgnore bool
+}
+
+// FilterFlags returns the cli flags of the Filter, with --hidden if hidden.
+func FilterFlags(hidden bool) []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringSliceFlag{Name: "exclude", Usage: "exclude the files matching `pattern`, like *.log or node_modules/"},
+		cli.StringSliceFlag{Name: "include", Usage: "do not exclude the files matching `pattern`"},
+		cli.StringSliceFlag{Name: "exclude-from", Usage: "read the exclude patterns from `file`, in gitignore syntax"},
+		cli.BoolFlag{Name: "gitignore", Usage: "honour the .gitignore files in the local source directories"},
+	}
+
+	if hidden {
+		flags = append(flags, cli.BoolFlag{Name: "hidden", Usage: "include the hidden files, whose names start with a dot"})
+	}
+
+	return flags
+}
+
+// ParseFilter creates the Filter from the FilterFlags.
+func ParseFilter(c *cli.Context) (*Filter, error) {
+	f := &Filter{
+		Include:   c.StringSlice("include"),
+		Exclude:   c.StringSlice("exclude"),
+		Hidden:    c.Bool("hidden"),
+		GitIgnore: c.Bool("gitignore"),
+	}
+
+	// the patterns of the files come before --exclude, in the order of the files.
+	var patterns []string
+	for _, file := range c.StringSlice("exclude-from") {
+		p, err := ReadPatterns(ss.ExpandHome(file))
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, p...)
+	}
+
+	f.Exclude = append(patterns, f.Exclude...)
+
+	return f, nil
+}
+
+// ReadPatterns reads the patterns from the file in gitignore syntax, the blank lines and comments are skipped.
+func ReadPatterns(file string) (patterns []string, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		if line := strings.TrimRight(sc.Text(), " \r"); line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return patterns, nil
+}
+
+// filterRule is a compiled pattern.
+type filterRule struct {
+	base     string // the directory of the .gitignore, relative to the root
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func parseRule(base, line string) (r filterRule) {
+	r.base = base
+
+	if strings.HasPrefix(line, "!") {
+		r.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		r.anchored, line = true, strings.TrimPrefix(line, "/")
+	}
+
+	r.pattern = line
+
+	return r
+}
+
+func (r filterRule) match(rel string, isDir bool) bool {
+	if r.pattern == "" || r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+
+		rel = rel[len(r.base)+1:]
+	}
+
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches the path segments by the pattern segments, ** matches zero or more segments.
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(patterns[0], names[0]); !ok {
+			return false
+		}
+
+		patterns, names = patterns[1:], names[1:]
+	}
+
+	return len(names) == 0
+}
+
+// rules returns the rules of the exclude patterns, then the include patterns as negated.
+func (f *Filter) rules() (rules []filterRule) {
+	for _, p := range f.Exclude {
+		rules = append(rules, parseRule("", p))
+	}
+
+	for _, p := range f.Include {
+		rules = append(rules, parseRule("", "!"+strings.TrimPrefix(p, "!")))
+	}
+
+	return rules
+}
+
+// Excluded tells the relative path from the transfer root is excluded.
+func (f *Filter) Excluded(rel string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+
+	return f.excluded(rel, isDir, f.rules())
+}
+
+func (f *Filter) excluded(rel string, isDir bool, rules []filterRule) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if rel == "" || rel == "." {
+		return false
+	}
+
+	excluded := !f.Hidden && isHiddenPath(rel)
+
+	for _, r := range rules {
+		if r.match(rel, isDir) {
+			excluded = !r.negate
+		}
+	}
+
+	return excluded
+}
+
+func isHiddenPath(rel string) bool {
+	for _, name := range strings.Split(rel, "/") {
+		if len(name) > 1 && name[0] == '.' && name != ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Walk walks the local root like filepath.Walk, the excluded files and directories are skipped,
+// and the .gitignore files in the directories are honoured if GitIgnore.
+// fn is called with the path and its relative slash path from root.
+func (f *Filter) Walk(root string, fn func(p, rel string, info os.FileInfo) error) error {
+	if f == nil {
+		f = &Filter{Hidden: true}
+	}
+
+	cliRules := f.rules()
+	gitRules := map[string][]filterRule{} // by the relative dir
+
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." {
+			var rules []filterRule
+			for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+				rules = append(append([]filterRule{}, gitRules[dir]...), rules...)
+				if dir == "." {
+					break
+				}
+			}
+
+			if f.excluded(rel, info.IsDir(), append(rules, cliRules...)) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+		}
+
+		if f.GitIgnore && info.IsDir() {
+			if patterns, err := ReadPatterns(filepath.Join(p, ".gitignore")); err == nil {
+				base := rel
+				if base == "." {
+					base = ""
+				}
+
+				for _, pattern := range patterns {
+					gitRules[rel] = append(gitRules[rel], parseRule(base, pattern))
+				}
+			}
+		}
+
+		return fn(p, rel, info)
+	})
+}
+
+// WalkDirFilter returns the paths under dir, not excluded by the filter, the directories end with /.
+func WalkDirFilter(dir string, f *Filter) (files []string, err error) {
+	if _, err = os.Lstat(dir); err != nil {
+		return nil, err
+	}
+
+	err = f.Walk(dir, func(p, _ string, info os.FileInfo) error {
+		if info.IsDir() {
+			p += "/"
+		}
+
+		files = append(files, p)
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,83 @@
+package common_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestFilter(t *testing.T) {
+	f := &common.Filter{
+		Exclude: []string{"*.log", "node_modules/", "/dist", "docs/**/*.tmp", "!keep.log"},
+		Include: []string{".env"},
+	}
+
+	assert.True(t, f.Excluded("a/b/debug.log", false))
+	assert.False(t, f.Excluded("a/keep.log", false))
+	assert.True(t, f.Excluded("web/node_modules", true))
+	assert.False(t, f.Excluded("web/node_modules", false))
+	assert.True(t, f.Excluded("dist", true))
+	assert.False(t, f.Excluded("web/dist", true))
+	assert.True(t, f.Excluded("docs/a/b/c.tmp", false))
+	assert.True(t, f.Excluded("docs/c.tmp", false))
+	assert.True(t, f.Excluded("a/.git", true))
+	assert.False(t, f.Excluded(".env", false))
+	assert.False(t, f.Excluded("main.go", false))
+
+	f.Hidden = true
+	assert.False(t, f.Excluded("a/.git", true))
+}
+
+func TestFilterWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"main.go", "app.log", ".bashrc", "web/index.html", "web/cache/x", "web/.gitignore"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, filepath.Dir(p)), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(root, p), []byte(p), 0o644))
+	}
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "web/.gitignore"), []byte("# cache\ncache/\n"), 0o644))
+
+	ignore := filepath.Join(t.TempDir(), "ignore")
+	assert.Nil(t, os.WriteFile(ignore, []byte("*.log\n"), 0o644))
+
+	walk := func(f *common.Filter) (rels []string) {
+		assert.Nil(t, f.Walk(root, func(_, rel string, _ os.FileInfo) error {
+			rels = append(rels, rel)
+			return nil
+		}))
+
+		return rels
+	}
+
+	patterns, err := common.ReadPatterns(ignore)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{".", "main.go", "web", "web/index.html"},
+		walk(&common.Filter{Exclude: patterns, GitIgnore: true}))
+	assert.Equal(t, []string{".", ".bashrc", "app.log", "main.go", "web", "web/.gitignore", "web/cache", "web/cache/x", "web/index.html"},
+		walk(&common.Filter{Hidden: true}))
+}
+
+func TestParseFilter(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	assert.Nil(t, os.WriteFile(a, []byte("*.log\n# comment\n\n"), 0o644))
+	assert.Nil(t, os.WriteFile(b, []byte("!keep.log\n"), 0o644))
+
+	var f *common.Filter
+
+	app := cli.NewApp()
+	app.Flags = common.FilterFlags(false)
+	app.Action = func(c *cli.Context) (err error) {
+		f, err = common.ParseFilter(c)
+		return err
+	}
+
+	assert.Nil(t, app.Run([]string{"test", "--exclude-from", a, "--exclude-from", b, "--exclude", "tmp/"}))
+	assert.Equal(t, []string{"*.log", "!keep.log", "tmp/"}, f.Exclude)
+	assert.False(t, f.Excluded("keep.log", false))
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"io"
+	"sync"
+
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/dustin/go-humanize"
+	"github.com/juju/ratelimit"
+)
+
+// ParseRate parses the bytes per second like 100K or 10MB, 0 for unlimited.
+func ParseRate(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	rate, err := humanize.ParseBytes(s)
+
+	return int64(rate), err
+}
+
+// RateLimit limits the transfers by the rate of each host, and the total rate shared by all the hosts.
+type RateLimit struct {
+	total *ratelimit.Bucket
+	rate  func(host string) int64
+
+	mu    sync.Mutex
+	hosts map[string]*ratelimit.Bucket
+}
+
+// NewRateLimit creates the RateLimit, total and the rate of the host <= 0 for unlimited.
+func NewRateLimit(total int64, rate func(host string) int64) *RateLimit {
+	return &RateLimit{total: newBucket(total), rate: rate, hosts: map[string]*ratelimit.Bucket{}}
+}
+
+func newBucket(rate int64) *ratelimit.Bucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	return ratelimit.NewBucketWithRate(float64(rate), rate)
+}
+
+// bucket returns the bucket of the host, shared by the parallel transfers of the host.
+func (l *RateLimit) bucket(host string) *ratelimit.Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.hosts[host]
+	if !ok && l.rate != nil {
+		b = newBucket(l.rate(host))
+		l.hosts[host] = b
+	}
+
+	return b
+}
+
+// Reader limits the reader of the transfer of the host,
+// a nil RateLimit limits by the RATELIMIT env like CreateRateLimit.
+func (l *RateLimit) Reader(host string, r io.Reader) io.Reader {
+	if l == nil {
+		return CreateRateLimit(r)
+	}
+
+	if b := l.bucket(host); b != nil {
+		r = ratelimit.Reader(r, b)
+	}
+
+	if l.total != nil {
+		r = ratelimit.Reader(r, l.total)
+	}
+
+	return r
+}
+
+// Limited reports whether the transfers of the host are limited,
+// a nil RateLimit is limited by the RATELIMIT env like CreateRateLimit.
+func (l *RateLimit) Limited(host string) bool {
+	if l == nil {
+		rate, _ := ss.GetenvBytes("RATELIMIT", 0)
+		return rate > 0
+	}
+
+	return l.total != nil || l.bucket(host) != nil
+}
+
+// ReaderFunc returns the Reader bound to the host.
+func (l *RateLimit) ReaderFunc(host string) func(io.Reader) io.Reader {
+	return func(r io.Reader) io.Reader { return l.Reader(host, r) }
+}
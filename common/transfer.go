@@ -0,0 +1,207 @@
+package common
+
+import (
+	"bytes"
+	"crypto/md5" // nolint
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// TransferOption is the options of the scp and sftp transfers.
+type TransferOption struct {
+	// Resume continues from the size of the existing destination, if its prefix is identical.
+	Resume bool
+
+	// Verify is the hash algorithm to verify the destination after the transfer, sha256 or md5.
+	Verify string
+}
+
+// ParseVerify checks the hash algorithm of --verify.
+func ParseVerify(algo string) (string, error) {
+	switch algo = strings.ToLower(algo); algo {
+	case "", "sha256", "md5":
+		return algo, nil
+	}
+
+	return "", fmt.Errorf("unknown verify algorithm %q, sha256 or md5", algo)
+}
+
+// HashFunc hashes the first n bytes of a file, the whole file if n < 0.
+type HashFunc func(n int64) (string, error)
+
+// LocalHasher returns the HashFunc of the local file.
+func LocalHasher(algo, path string) HashFunc {
+	return func(n int64) (string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+
+		defer f.Close()
+
+		return hashReader(algo, f, n)
+	}
+}
+
+// RemoteHasher returns the HashFunc of the remote file, by sha256sum or md5sum over an exec session,
+// or reading the file back over sftp if the command is not available.
+func RemoteHasher(client *ssh.Client, ftp *sftp.Client, algo, path string) HashFunc {
+	return func(n int64) (string, error) {
+		if client != nil {
+			if sum, err := remoteHashCommand(client, algo, path, n); err == nil {
+				return sum, nil
+			}
+		}
+
+		f, err := ftp.Open(path)
+		if err != nil {
+			return "", err
+		}
+
+		defer f.Close()
+
+		return hashReader(algo, f, n)
+	}
+}
+
+func remoteHashCommand(client *ssh.Client, algo, path string, n int64) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+
+	defer session.Close()
+
+	command := algo + "sum < " + ShellQuote(path)
+	if n >= 0 {
+		command = "head -c " + strconv.FormatInt(n, 10) + " < " + ShellQuote(path) + " | " + algo + "sum"
+	}
+
+	var stdout bytes.Buffer
+	session.Stdout = &stdout
+
+	if err := session.Run(command); err != nil {
+		return "", err
+	}
+
+	sum, _, _ := strings.Cut(strings.TrimSpace(stdout.String()), " ")
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != newHash(algo).Size()*2 {
+		return "", fmt.Errorf("unexpected output of %s: %q", command, stdout.String())
+	}
+
+	return sum, nil
+}
+
+func newHash(algo string) hash.Hash {
+	if algo == "md5" {
+		return md5.New() // nolint
+	}
+
+	return sha256.New()
+}
+
+func hashReader(algo string, r io.Reader, n int64) (string, error) {
+	h := newHash(algo)
+
+	if n >= 0 {
+		r = io.LimitReader(r, n)
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ResumeOffset returns the offset to continue the transfer from, the size of the destination
+// if resume and the prefix of the source is identical to the destination, or 0.
+func (o TransferOption) ResumeOffset(srcSize, dstSize int64, src, dst HashFunc) int64 {
+	if !o.Resume || dstSize <= 0 || dstSize > srcSize {
+		return 0
+	}
+
+	srcSum, err := src(dstSize)
+	if err != nil {
+		return 0
+	}
+
+	if dstSum, err := dst(dstSize); err != nil || dstSum != srcSum {
+		return 0
+	}
+
+	return dstSize
+}
+
+// SeekResume seeks the source and destination to the offset of resume,
+// and notes the resumed path to w if it is not nil.
+func SeekResume(offset int64, src, dst io.Seeker, w io.Writer, path string) error {
+	if offset <= 0 {
+		return nil
+	}
+
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if w != nil {
+		fmt.Fprintf(w, "resume %s from %s\n", path, ss.IBytes(uint64(offset)))
+	}
+
+	return nil
+}
+
+// VerifyHash compares the hashes of the source and the destination, if verify.
+func (o TransferOption) VerifyHash(src, dst HashFunc) error {
+	if o.Verify == "" {
+		return nil
+	}
+
+	srcSum, err := src(-1)
+	if err != nil {
+		return fmt.Errorf("verify %s source: %w", o.Verify, err)
+	}
+
+	dstSum, err := dst(-1)
+	if err != nil {
+		return fmt.Errorf("verify %s destination: %w", o.Verify, err)
+	}
+
+	if srcSum != dstSum {
+		return fmt.Errorf("%s mismatch, source %s, destination %s", o.Verify, srcSum, dstSum)
+	}
+
+	return nil
+}
+
+// HashAlgo returns the hash algorithm of the resume and verify, sha256 by default.
+func (o TransferOption) HashAlgo() string {
+	if o.Verify == "" {
+		return "sha256"
+	}
+
+	return o.Verify
+}
+
+// OpenFlag returns the flag to open the destination, truncated unless continued from offset.
+func OpenFlag(offset int64) int {
+	if offset > 0 {
+		return os.O_RDWR | os.O_CREATE
+	}
+
+	return os.O_RDWR | os.O_CREATE | os.O_TRUNC
+}
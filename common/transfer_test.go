@@ -0,0 +1,51 @@
+package common_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferOption(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	assert.Nil(t, os.WriteFile(src, []byte("hello world"), 0o644))
+	assert.Nil(t, os.WriteFile(dst, []byte("hello"), 0o644))
+
+	o := common.TransferOption{Resume: true, Verify: "md5"}
+	srcHash, dstHash := common.LocalHasher(o.HashAlgo(), src), common.LocalHasher(o.HashAlgo(), dst)
+	assert.Equal(t, int64(5), o.ResumeOffset(11, 5, srcHash, dstHash))
+	assert.Equal(t, int64(0), common.TransferOption{}.ResumeOffset(11, 5, srcHash, dstHash))
+	assert.EqualError(t, o.VerifyHash(srcHash, dstHash),
+		"md5 mismatch, source 5eb63bbbe01eeed093cb22bb8f5acdc3, destination 5d41402abc4b2a76b9719d911017c592")
+
+	assert.Nil(t, os.WriteFile(dst, []byte("help"), 0o644))
+	assert.Equal(t, int64(0), o.ResumeOffset(11, 4, srcHash, dstHash))
+
+	assert.Nil(t, os.WriteFile(dst, []byte("hello world"), 0o644))
+	assert.Nil(t, o.VerifyHash(srcHash, dstHash))
+
+	_, err := common.ParseVerify("crc32")
+	assert.NotNil(t, err)
+}
+
+func TestSeekResume(t *testing.T) {
+	src, dst := strings.NewReader("hello world"), strings.NewReader("hello")
+
+	var note bytes.Buffer
+	assert.Nil(t, common.SeekResume(5, src, dst, &note, "/tmp/a"))
+	assert.Equal(t, "resume /tmp/a from 5 B\n", note.String())
+
+	rest, _ := io.ReadAll(src)
+	assert.Equal(t, " world", string(rest))
+
+	note.Reset()
+	assert.Nil(t, common.SeekResume(0, src, dst, &note, "/tmp/a"))
+	assert.Equal(t, "", note.String())
+}
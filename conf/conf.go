@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/sshlib"
 	"github.com/bingoohuang/ngg/gossh/pkg/hostparse"
@@ -30,13 +31,41 @@ import (
 type HostInfo struct {
 	Info   string `json:"info"`
 	Update string `json:"update,omitempty"`
+	// LastUsed is the time of the latest shell connection to the server.
+	LastUsed string `json:"lastUsed,omitempty"`
+}
+
+// UpdateHostInfo updates the cached host info of server by fn, and saves it to HostInfoJsonFile.
+func (cf *Config) UpdateHostInfo(server string, fn func(h *HostInfo)) {
+	if cf.HostInfo == nil {
+		cf.HostInfo = map[string]HostInfo{}
+	}
+
+	h := cf.HostInfo[server]
+	fn(&h)
+	cf.HostInfo[server] = h
+
+	if cf.HostInfoJsonFile == "" {
+		return
+	}
+
+	hostInfoJson, _ := json.Marshal(cf.HostInfo)
+	if len(hostInfoJson) > 0 {
+		if err := os.WriteFile(cf.HostInfoJsonFile, hostInfoJson, os.ModePerm); err != nil {
+			log.Printf("write %q error: %v", cf.HostInfoJsonFile, err)
+		}
+	}
 }
 
 // Config is Struct that stores the entire configuration file.
 type Config struct {
 	Extra    ExtraConfig
 	Log      LogConfig
+	Redact   RedactConfig
+	Audit    AuditConfig
 	Shell    ShellConfig
+	TUI      TUIConfig `toml:"tui"`
+	Transfer TransferConfig
 	Include  map[string]IncludeConfig
 	Includes IncludesConfig
 	Common   ServerConfig
@@ -72,10 +101,13 @@ type ExtraConfig struct {
 	Grouping DefaultTrue
 	// AutoEncryptPwd disables auto PBE passwords in config file.
 	AutoEncryptPwd DefaultTrue
+	// Probe sets the reachability probe of servers in the picker: tcp(default), ssh(handshake) or off.
+	Probe string
 }
 
 // LogConfig store the contents about the terminal log.
-// The log file name is created in "YYYYmmdd_HHMMSS_servername.log" of the specified directory.
+// The log file name is created in "YYYYmmdd_servername.log" of the specified directory,
+// or "YYYYmmdd_HHMMSS_servername.cast" in asciicast format.
 type LogConfig struct {
 	// Enable terminal logging.
 	Enable bool
@@ -85,6 +117,113 @@ type LogConfig struct {
 
 	// Specifies the directory for creating terminal logs.
 	Dir string `toml:"dirpath"`
+
+	// Format of terminal logs, "text"(default) or "asciicast" (asciinema v2 recording with timing).
+	Format string
+
+	// Combined writes the output of cmd and pshell runs to one log file with host prefixes, instead of per host.
+	Combined bool
+}
+
+// RedactConfig store the rules to mask the secrets in the terminal logs and pshell history.
+type RedactConfig struct {
+	// Patterns are the regexps of secrets, only the first group is masked if the pattern has a group.
+	Patterns []string
+	// Builtin enables the built-in detectors of common tokens, like AWS/GitHub/Slack tokens, JWT and TOKEN=xxx.
+	// It is off by default, the logs are kept as is unless redaction is configured.
+	Builtin bool
+}
+
+// Redactor returns the redactor of the rules, the invalid patterns are logged and ignored.
+func (r RedactConfig) Redactor() *sshlib.Redactor {
+	redactor, errs := sshlib.NewRedactor(r.Patterns, r.Builtin)
+	for _, err := range errs {
+		log.Printf("invalid [redact] pattern: %v", err)
+	}
+
+	return redactor
+}
+
+// AuditConfig store the settings of the audit trail of the executed actions,
+// like connections, commands, transfers and forwards.
+type AuditConfig struct {
+	// Enable the audit trail.
+	Enable bool
+
+	// Specifies the directory of the file sink, default ~/.bssh/audit.
+	Dir string `toml:"dirpath"`
+
+	// Sinks of the audit records: file(default), syslog, or a http(s) url to post the records to.
+	Sinks []string
+}
+
+// Setup sets the audit sinks if enabled, the commands and paths are masked by redactor.
+func (a AuditConfig) Setup(redactor *sshlib.Redactor) {
+	if !a.Enable {
+		return
+	}
+
+	dir := ss.ExpandHome(ss.Or(a.Dir, "~/.bssh/audit"))
+	if err := audit.Setup(dir, a.Sinks, redactor.Redact); err != nil {
+		log.Printf("setup [audit]: %v", err)
+	}
+}
+
+// TUIConfig store the settings of the TUI server list.
+type TUIConfig struct {
+	// Vi starts the list in vi normal mode, j/k/g/G moves the cursor, / or i types the filter.
+	Vi bool
+	// Keys rebinds the actions to keys, like toggle = ["tab", "ctrl+t"].
+	Keys map[string][]string
+}
+
+// TransferConfig store the bandwidth limits of the transfers, in bytes per second like 10M.
+type TransferConfig struct {
+	// RateLimit is the limit of each server, overridden by the limits of its groups and its own rate_limit.
+	RateLimit string `toml:"rate_limit"`
+	// TotalRateLimit is the aggregate limit shared by all the servers of a multi-host transfer.
+	TotalRateLimit string `toml:"total_rate_limit"`
+	// Group is the limits of the servers in the groups, like "prod/db" = "1M".
+	Group map[string]string
+	// Forward limits the port forwards as well.
+	Forward bool
+}
+
+// RateLimit returns the bytes per second limit of the server, 0 is unlimited.
+// It is the rate_limit of the server, or of its most specific group in [transfer.group],
+// or the rate_limit of [transfer], or the RATELIMIT env.
+func (cf *Config) RateLimit(server string) int64 {
+	s := cf.Server[server].RateLimit
+
+	if s == "" {
+		matched := ""
+		for _, g := range cf.Server[server].Group {
+			for node, limit := range cf.Transfer.Group {
+				if InGroupTree(g, node) && len(node) > len(matched) {
+					matched, s = node, limit
+				}
+			}
+		}
+	}
+
+	s = ss.Or(s, ss.Or(cf.Transfer.RateLimit, os.Getenv("RATELIMIT")))
+
+	rate, err := common.ParseRate(s)
+	if err != nil {
+		log.Fatalf("failed to parse rate limit of %s error %v", server, err)
+	}
+
+	return rate
+}
+
+// NewRateLimit creates the limit of a transfer, the total limit is shared by all its servers.
+func (cf *Config) NewRateLimit() *common.RateLimit {
+	total, err := common.ParseRate(cf.Transfer.TotalRateLimit)
+	if err != nil {
+		log.Fatalf("failed to parse total_rate_limit error %v", err)
+	}
+
+	return common.NewRateLimit(total, cf.RateLimit)
 }
 
 // ShellConfig structure for storing bssh-shell settings.
@@ -102,6 +241,13 @@ type ShellConfig struct {
 	// pre | post command setting
 	PreCmd  string `toml:"pre_cmd"`
 	PostCmd string `toml:"post_cmd"`
+
+	// parallel shell options, can be changed by %set in the session.
+	Header   DefaultTrue  // print the output prompt before the lines of hosts
+	Collapse bool         // print the identical outputs of hosts once, after the command exits
+	Timeout  TomlDuration // per-command timeout, like 30s, 0 is unlimited
+	TrackCd  bool         `toml:"track_cd"` // run the following commands in the directory of `cd dir`
+	Color    string       // color the server names: auto(default), always or never
 }
 
 // IncludeConfig specify the configuration file to include (ServerConfig only).
@@ -178,7 +324,10 @@ type ServerConfig struct {
 
 	// Dynamic Port Forwarding setting
 	DynamicPortForward string `toml:"dynamic_port_forward"` // ex.) "11080"
-	Note               string
+
+	// bandwidth limit of the transfers, bytes per second like 10M, see also TransferConfig.
+	RateLimit string `toml:"rate_limit"`
+	Note      string
 
 	// Connection Timeout second
 	ConnectTimeout int `toml:"connect_timeout"`
@@ -355,6 +504,9 @@ func ReadConf(confPath string) (config Config) {
 	// Check Config Parameter
 	CheckFormatServerConf(config)
 	config.parseGroups()
+	if config.Audit.Enable {
+		config.Audit.Setup(config.Redact.Redactor())
+	}
 
 	return config
 }
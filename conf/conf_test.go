@@ -169,3 +169,71 @@ func TestGetNameList(t *testing.T) {
 		assert.Equal(t, v.expect, got, v.desc)
 	}
 }
+
+func TestInGroupTree(t *testing.T) {
+	type TestData struct {
+		desc   string
+		group  string
+		node   string
+		expect bool
+	}
+
+	tds := []TestData{
+		{desc: "Same group", group: "prod", node: "prod", expect: true},
+		{desc: "Child group", group: "prod/web", node: "prod", expect: true},
+		{desc: "Grandchild group", group: "prod/web/a", node: "prod", expect: true},
+		{desc: "Prefix but not child", group: "production", node: "prod", expect: false},
+		{desc: "Parent group", group: "prod", node: "prod/web", expect: false},
+		{desc: "Root node", group: "prod", node: "", expect: true},
+		{desc: "Trailing slash", group: "prod/web", node: "prod/", expect: true},
+	}
+
+	for _, v := range tds {
+		assert.Equal(t, v.expect, conf.InGroupTree(v.group, v.node), v.desc)
+	}
+}
+
+func TestFilterNamesByGroups(t *testing.T) {
+	cf := conf.Config{
+		Server: map[string]conf.ServerConfig{
+			"web1": {Group: []string{"prod/web"}},
+			"db1":  {Group: []string{"prod/db"}},
+			"app1": {Group: []string{"production"}},
+			"dev1": {Group: []string{"dev"}},
+		},
+	}
+	names := []string{"app1", "db1", "dev1", "web1"}
+
+	assert.Equal(t, []string{"db1", "web1"}, cf.FilterNamesByGroups([]string{"prod"}, names))
+	assert.Equal(t, []string{"web1"}, cf.FilterNamesByGroups([]string{"prod/web"}, names))
+	assert.Equal(t, []string{"db1", "dev1"}, cf.FilterNamesByGroups([]string{"prod/db", "dev"}, names))
+	assert.Equal(t, names, cf.FilterNamesByGroups(nil, names))
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Setenv("RATELIMIT", "")
+
+	cf := conf.Config{
+		Transfer: conf.TransferConfig{
+			RateLimit: "10M",
+			Group:     map[string]string{"prod": "5M", "prod/db": "1M"},
+		},
+		Server: map[string]conf.ServerConfig{
+			"web1": {Group: []string{"prod/web"}},
+			"db1":  {Group: []string{"prod/db"}},
+			"db2":  {Group: []string{"prod/db"}, RateLimit: "2KiB"},
+			"dev1": {Group: []string{"dev"}},
+		},
+	}
+
+	assert.Equal(t, int64(5_000_000), cf.RateLimit("web1"))
+	assert.Equal(t, int64(1_000_000), cf.RateLimit("db1"))
+	assert.Equal(t, int64(2048), cf.RateLimit("db2"))
+	assert.Equal(t, int64(10_000_000), cf.RateLimit("dev1"))
+
+	cf.Transfer.RateLimit = ""
+	assert.Equal(t, int64(0), cf.RateLimit("dev1"))
+
+	t.Setenv("RATELIMIT", "100K")
+	assert.Equal(t, int64(100_000), cf.RateLimit("dev1"))
+}
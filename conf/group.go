@@ -18,7 +18,7 @@ func (c ServerConfig) BelongsToGroup(cf *Config, name string) bool {
 	}
 
 	for _, g := range c.Group {
-		if strings.HasPrefix(g, name) {
+		if InGroupTree(g, name) {
 			return true
 		}
 	}
@@ -26,6 +26,56 @@ func (c ServerConfig) BelongsToGroup(cf *Config, name string) bool {
 	return false
 }
 
+// InGroupTree tells group is the node or a descendant of the node in the "/" separated group tree.
+// e.g. "prod/web" is in the tree of "prod", but "production" is not.
+func InGroupTree(group, node string) bool {
+	group, node = strings.Trim(group, "/"), strings.Trim(node, "/")
+	return node == "" || group == node || strings.HasPrefix(group, node+"/")
+}
+
+// GroupNode is a node of the "/" separated group tree.
+type GroupNode struct {
+	Path     string // full group path, like prod/web
+	HasChild bool   // has child groups or not
+}
+
+// ChildGroups returns the child nodes of parent in the group tree, the root is "".
+// Servers without groups are gathered in the others group at the root.
+func (cf *Config) ChildGroups(parent string) []GroupNode {
+	parent = strings.Trim(parent, "/")
+	nodes := make(map[string]bool)
+
+	for group := range cf.grouping {
+		group = strings.Trim(group, "/")
+		if group == "" {
+			if parent != "" {
+				continue
+			}
+
+			group = cf.pickOthersGroupName()
+		} else if parent != "" && !strings.HasPrefix(group, parent+"/") {
+			continue
+		}
+
+		rest := strings.TrimPrefix(strings.TrimPrefix(group, parent), "/")
+		child, _, hasChild := strings.Cut(rest, "/")
+		if parent != "" {
+			child = parent + "/" + child
+		}
+
+		nodes[child] = nodes[child] || hasChild
+	}
+
+	children := make([]GroupNode, 0, len(nodes))
+	for path, hasChild := range nodes {
+		children = append(children, GroupNode{Path: path, HasChild: hasChild})
+	}
+
+	sort.Slice(children, func(i, j int) bool { return children[i].Path < children[j].Path })
+
+	return children
+}
+
 func (cf *Config) parseGroups() {
 	cf.grouping = make(map[string]map[string]ServerConfig)
 
@@ -67,6 +117,30 @@ func (cf *Config) FilterNamesByGroup(group string, names []string) []string {
 	return x
 }
 
+// FilterNamesByGroups filter server names belongs to any of the groups.
+func (cf *Config) FilterNamesByGroups(groups []string, names []string) []string {
+	if len(groups) == 1 {
+		return cf.FilterNamesByGroup(groups[0], names)
+	}
+
+	if !cf.Extra.Grouping.Get() || len(groups) == 0 {
+		return names
+	}
+
+	x := make([]string, 0, len(names))
+	for _, name := range names {
+		sc := cf.Server[name]
+		for _, group := range groups {
+			if sc.BelongsToGroup(cf, group) {
+				x = append(x, name)
+				break
+			}
+		}
+	}
+
+	return x
+}
+
 // GroupsNames get groups' names.
 func (cf *Config) GroupsNames() []string {
 	otherGroupName := cf.pickOthersGroupName()
@@ -2,23 +2,26 @@ package list
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bingoohuang/bssh/conf"
+	sshcmd "github.com/bingoohuang/bssh/ssh"
 	"github.com/bingoohuang/ngg/ss"
 	"github.com/mozillazg/go-pinyin"
 )
 
 // ShowServersView shows view for servers.
 func ShowServersView(cf *conf.Config, prompt string, names []string, isMulti bool) []string {
-	group := showGroupsView(cf)
+	groups := showGroupsView(cf, names, isMulti)
 
 	// View List And Get Select Line
-	l := new(Info)
+	l := newInfo(cf)
 	l.Prompt = prompt
-	l.NameList = cf.FilterNamesByGroup(group, names)
+	l.NameList = cf.FilterNamesByGroups(groups, names)
 	hostInfoEnabled := cf.HostInfoEnabled.Get()
 	if hostInfoEnabled {
 		l.SetTitle([]string{"ServerName", "Connect Info # Note", "Host Info"})
@@ -47,6 +50,8 @@ func ShowServersView(cf *conf.Config, prompt string, names []string, isMulti boo
 
 		return row
 	}
+	l.PreviewFn = func(name string) []string { return serverPreview(cf, name) }
+	l.ProbeFn = serverProbeFn(cf)
 	l.MultiFlag = isMulti
 
 	l.View()
@@ -60,29 +65,217 @@ func ShowServersView(cf *conf.Config, prompt string, names []string, isMulti boo
 	return selected
 }
 
-// showGroupsView shows view for groups.
-func showGroupsView(cf *conf.Config) string {
+// newInfo creates the list with the [tui] settings.
+func newInfo(cf *conf.Config) *Info {
+	return &Info{KeyBindings: cf.TUI.Keys, ViMode: cf.TUI.Vi}
+}
+
+// probeTimeout is the timeout of the reachability probe of a server.
+const probeTimeout = 3 * time.Second
+
+// serverProbeFn returns the reachability probe function by the [extra] probe setting, nil if it is off.
+func serverProbeFn(cf *conf.Config) func(name string) (time.Duration, error) {
+	mode := strings.ToLower(cf.Extra.Probe)
+	if mode == "off" {
+		return nil
+	}
+
+	// probes run in background, so they use a snapshot of the servers and proxies,
+	// which may be changed after the server selected.
+	snapshot := conf.Config{Server: maps.Clone(cf.Server), Proxy: maps.Clone(cf.Proxy)}
+	handshake := mode == "ssh"
+
+	return func(name string) (time.Duration, error) {
+		return sshcmd.Probe(name, snapshot, handshake, probeTimeout)
+	}
+}
+
+// serverPreview returns the detail lines of server for the preview pane.
+// Secrets, like passwords and key passphrases, are never included.
+func serverPreview(cf *conf.Config, name string) []string {
+	s := cf.Server[name]
+	lines := []string{
+		"Connect   : " + s.User + "@" + s.Addr + ss.If(s.Port != "", ":"+s.Port, ""),
+	}
+
+	route, err := sshcmd.ProxyRoute(name, *cf)
+	switch {
+	case err != nil:
+		route = err.Error()
+	case route == "":
+		route = "direct"
+	}
+	lines = append(lines, "Proxy     : "+route)
+	lines = append(lines, "Auth      : "+strings.Join(authMethods(s), ", "))
+
+	if len(s.Group) > 0 {
+		lines = append(lines, "Groups    : "+strings.Join(s.Group, ", "))
+	}
+	if forwards := portForwards(s); len(forwards) > 0 {
+		lines = append(lines, "Forwards  : "+strings.Join(forwards, ", "))
+	}
+	if s.InitialCmd != "" {
+		lines = append(lines, "InitialCmd: "+s.InitialCmd)
+	}
+
+	hostInfo := cf.HostInfo[name]
+	if hostInfo.LastUsed != "" {
+		lines = append(lines, "Last Used : "+hostInfo.LastUsed)
+	}
+	if hostInfo.Info != "" {
+		lines = append(lines, "Host Info : "+strings.TrimSpace(hostInfo.Info)+ss.If(hostInfo.Update != "", " ("+hostInfo.Update+")", ""))
+	}
+
+	return lines
+}
+
+// authMethods returns the configured auth methods of server, without secrets.
+func authMethods(s conf.ServerConfig) (methods []string) {
+	if s.Pass != "" || len(s.Passes) > 0 {
+		methods = append(methods, "password")
+	}
+	if s.Key != "" {
+		methods = append(methods, "key("+ss.Or(s.OriginalKey, s.Key)+")")
+	}
+	for _, key := range s.Keys {
+		// key format is "path::passphrase"
+		methods = append(methods, "key("+strings.SplitN(key, "::", 2)[0]+")")
+	}
+	if s.KeyCommand != "" {
+		methods = append(methods, "keycmd")
+	}
+	if s.Cert != "" {
+		methods = append(methods, "cert("+s.Cert+")")
+	}
+	if s.AgentAuth {
+		methods = append(methods, "agent")
+	}
+	if s.PKCS11Use {
+		methods = append(methods, "pkcs11("+s.PKCS11Provider+")")
+	}
+	if len(methods) == 0 {
+		methods = append(methods, "none")
+	}
+
+	return methods
+}
+
+// portForwards returns the port forwarding settings of server.
+func portForwards(s conf.ServerConfig) (forwards []string) {
+	if s.PortForwardLocal != "" && s.PortForwardRemote != "" {
+		if s.PortForwardMode == "R" {
+			forwards = append(forwards, "R local["+s.PortForwardLocal+"] <= remote["+s.PortForwardRemote+"]")
+		} else {
+			forwards = append(forwards, "L local["+s.PortForwardLocal+"] => remote["+s.PortForwardRemote+"]")
+		}
+	}
+	if s.DynamicPortForward != "" {
+		forwards = append(forwards, "D "+s.DynamicPortForward)
+	}
+
+	return forwards
+}
+
+// groupUp is the row name to back up to the parent group.
+const groupUp = ".."
+
+// showGroupsView shows view for the "/" separated group tree, and returns the selected groups.
+// Enter on a group with children drills down into it, and ".." backs up.
+// The current group row selects its whole subtree, and in multi mode several subtrees can be selected.
+func showGroupsView(cf *conf.Config, names []string, isMulti bool) []string {
 	if !cf.Extra.Grouping.Get() || len(cf.GetGrouping()) <= 1 {
-		return ""
+		return nil
 	}
 
-	// View List And Get Select Line
-	l := new(Info)
-	l.Prompt = "group>>"
-	l.NameList = cf.GroupsNames()
-	l.SetTitle([]string{"GroupName"})
-	l.RowFn = func(name string) string { return name }
-	l.MultiFlag = false
+	parent := ""
 
-	l.View()
-	selected := l.SelectName
+	for {
+		l := newInfo(cf)
+		l.Prompt = "group" + ss.If(parent != "", "("+parent+")", "") + ">>"
+		l.NameList, l.RowFn = groupRows(cf, parent, names)
+		l.SetTitle([]string{"GroupName", "Hosts"})
+		l.MultiFlag = isMulti
 
-	if selected[0] == "GroupName" {
-		fmt.Fprintln(os.Stderr, "Group not selected.")
-		os.Exit(1)
+		l.View()
+		selected := l.SelectName
+
+		if selected[0] == "GroupName" {
+			fmt.Fprintln(os.Stderr, "Group not selected.")
+			os.Exit(1)
+		}
+
+		if len(selected) == 1 {
+			name := selected[0]
+			if name == groupUp {
+				parent = parentGroup(parent)
+				continue
+			}
+
+			if name != parent && strings.HasSuffix(name, "/") {
+				parent = strings.TrimSuffix(name, "/")
+				continue
+			}
+		}
+
+		var groups []string
+		for _, name := range selected {
+			if name != groupUp {
+				groups = append(groups, strings.TrimSuffix(name, "/"))
+			}
+		}
+
+		if len(groups) == 0 {
+			parent = parentGroup(parent)
+			continue
+		}
+
+		return groups
+	}
+}
+
+// groupRows returns the row names and the row function of the group tree view under parent.
+// Groups with children are named with a "/" suffix.
+func groupRows(cf *conf.Config, parent string, names []string) ([]string, func(string) string) {
+	var rows []string
+	counts := make(map[string]int)
+
+	if parent != "" {
+		rows = append(rows, groupUp, parent)
+		counts[parent] = len(cf.FilterNamesByGroup(parent, names))
+	}
+
+	for _, node := range cf.ChildGroups(parent) {
+		count := len(cf.FilterNamesByGroup(node.Path, names))
+		if count == 0 {
+			continue
+		}
+
+		row := node.Path + ss.If(node.HasChild, "/", "")
+		rows = append(rows, row)
+		counts[row] = count
+	}
+
+	rowFn := func(name string) string {
+		switch name {
+		case groupUp:
+			return name + "	(up)"
+		case parent:
+			return name + "	" + fmt.Sprintf("%d", counts[name]) + " (all)"
+		default:
+			return name + "	" + fmt.Sprintf("%d", counts[name])
+		}
+	}
+
+	return rows, rowFn
+}
+
+// parentGroup returns the parent of group in the group tree.
+func parentGroup(group string) string {
+	if i := strings.LastIndex(group, "/"); i >= 0 {
+		return group[:i]
 	}
 
-	return selected[0]
+	return ""
 }
 
 // GetChinesePinyinInitials 将字符串中的汉字为拼音首字母。
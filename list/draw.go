@@ -23,50 +23,28 @@ func drawLine(x, y int, str string, colorNum int, backColorNum int) {
 	}
 }
 
-// Highlight lines and draw text based on filtering results.
+// Highlight the chars matched by the filter keyword.
 func drawFilterLine(x, y int, str string, backColorNum, keywordColorNum int, searchText string) {
-	// SearchText Bounds Space
-	searchWords := strings.Fields(searchText)
-
-	for i := 0; i < len(searchWords); i++ {
-		searchLowLine := strings.ToLower(str)
-		searchKeyword := strings.ToLower(searchWords[i])
-		searchKeywordLen := len(searchKeyword)
-		searchKeywordCount := strings.Count(searchLowLine, searchKeyword)
-
-		charLocation := 0
-
-		for j := 0; j < searchKeywordCount; j++ {
-			searchLineData := ""
-
-			// Countermeasure "slice bounds out of range"
-			if charLocation < len(str) {
-				searchLineData = str[charLocation:]
-			}
-
-			searchLineDataStr := searchLineData
-			searchKeywordIndex := strings.Index(strings.ToLower(searchLineDataStr), searchKeyword)
-
-			charLocation += searchKeywordIndex
-			keyword := ""
+	positions := matchPositions(str, searchText)
+	if len(positions) == 0 {
+		return
+	}
 
-			// Countermeasure "slice bounds out of range"
-			if charLocation < len(str) {
-				keyword = str[charLocation : charLocation+searchKeywordLen]
-			}
+	color := termbox.Attribute(keywordColorNum + 1)
+	backColor := termbox.Attribute(backColorNum + 1)
 
-			// Get Multibyte Charctor Location
-			multibyteStrCheckLine := str[:charLocation]
-			multiByteCharLocation := 0
+	// Get Multibyte Charctor Location
+	pi := 0
+	for i, char := range []rune(str) {
+		if pi < len(positions) && positions[pi] == i {
+			termbox.SetCell(x, y, char, color, backColor)
 
-			for _, multiByteChar := range multibyteStrCheckLine {
-				multiByteCharLocation += runewidth.RuneWidth(multiByteChar)
+			for pi < len(positions) && positions[pi] == i {
+				pi++
 			}
-
-			drawLine(x+multiByteCharLocation, y, keyword, keywordColorNum, backColorNum)
-
-			charLocation += searchKeywordLen
 		}
+
+		x += runewidth.RuneWidth(char)
 	}
 }
 
@@ -80,8 +58,8 @@ func (l *Info) draw() {
 	_ = termbox.Clear(termbox.Attribute(l.Term.Color+1), termbox.Attribute(l.Term.BackgroundColor+1))
 
 	// Get Terminal Size
-	_, height := termbox.Size()
-	height -= l.Term.Headline
+	preview := l.previewLines()
+	height := l.listHeight(len(preview))
 
 	// Set View List Range
 	firstLine := (l.CursorLine/height)*height + 1
@@ -98,6 +76,8 @@ func (l *Info) draw() {
 
 	l.drawViewHead()
 	l.drawViewList(viewList, cursor)
+	l.drawPreview(preview)
+	l.drawHelp()
 
 	// Multi-Byte SetCursor
 	x := l.countKeywordRuneWidth()
@@ -141,6 +121,27 @@ func (l *Info) drawViewList(viewList []string, cursor int) {
 
 		// Keyword Highlight
 		drawFilterLine(l.Term.LeftMargin, listKey+l.Term.Headline, paddingData, cursorBackColor, keywordColor, l.Keyword)
+
+		// Reachability status
+		if l.probe != nil {
+			l.drawProbeMark(listKey+l.Term.Headline, listValue, cursorColor, cursorBackColor)
+		}
+	}
+}
+
+// drawProbeMark draws the reachability dot at the left margin, and the latency at the right end of line.
+func (l *Info) drawProbeMark(y int, line string, color, backColor int) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	dot, dotColor, latency := l.probeMark(fields[0])
+	drawLine(0, y, string(dot), dotColor, l.Term.BackgroundColor)
+
+	if latency != "" {
+		width, _ := termbox.Size()
+		drawLine(width-runewidth.StringWidth(latency)-1, y, latency, color, backColor)
 	}
 }
 
@@ -148,4 +149,108 @@ func (l *Info) drawViewHead() {
 	drawLine(0, 0, l.Prompt, 3, l.Term.BackgroundColor)
 	drawLine(len(l.Prompt), 0, l.Keyword, l.Term.Color, l.Term.BackgroundColor)
 	drawLine(l.Term.LeftMargin, 1, l.ViewText[0], 3, l.Term.BackgroundColor)
+
+	// vi mode indicator
+	if l.ViMode {
+		mode := "-- FILTER --"
+		if l.normalMode {
+			mode = "-- NORMAL --"
+		}
+
+		width, _ := termbox.Size()
+		drawLine(width-len(mode)-1, 0, mode, 3, l.Term.BackgroundColor)
+	}
+}
+
+// drawHelp draws the help window at the center of terminal.
+func (l *Info) drawHelp() {
+	if l.helpLines == nil {
+		return
+	}
+
+	boxWidth := 0
+	for _, line := range l.helpLines {
+		if w := runewidth.StringWidth(line); w > boxWidth {
+			boxWidth = w
+		}
+	}
+	boxWidth += 4
+
+	width, height := termbox.Size()
+	x := (width - boxWidth) / 2
+	y := (height - len(l.helpLines) - 2) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	blank := strings.Repeat(" ", boxWidth)
+	drawLine(x, y, blank, 0, 7)
+	for i, line := range l.helpLines {
+		drawLine(x, y+i+1, blank, 0, 7)
+		drawLine(x+2, y+i+1, line, 0, 7)
+	}
+	drawLine(x, y+len(l.helpLines)+1, blank, 0, 7)
+}
+
+// previewHeight is the max lines of preview pane, include its title line.
+const previewHeight = 10
+
+// listHeight returns the number of list lines, except the headline and the preview pane.
+func (l *Info) listHeight(previewLines int) int {
+	_, height := termbox.Size()
+	height -= l.Term.Headline + previewLines
+
+	if height < 1 {
+		height = 1
+	}
+
+	return height
+}
+
+// previewLines returns the preview pane lines of cursor line.
+// The lines is padded to a fixed height, so that the list range does not change when the cursor moves.
+func (l *Info) previewLines() []string {
+	if !l.Preview || l.PreviewFn == nil || l.CursorLine+1 >= len(l.ViewText) {
+		return nil
+	}
+
+	fields := strings.Fields(l.ViewText[l.CursorLine+1])
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, height := termbox.Size()
+	maxLines := previewHeight
+	if half := (height - l.Term.Headline) / 2; half < maxLines {
+		maxLines = half
+	}
+
+	if maxLines < 2 {
+		return nil
+	}
+
+	lines := append([]string{"-- " + fields[0] + " --"}, l.PreviewFn(fields[0])...)
+	for len(lines) < maxLines {
+		lines = append(lines, "")
+	}
+
+	return lines[:maxLines]
+}
+
+// drawPreview draws the preview pane at the bottom of terminal.
+func (l *Info) drawPreview(lines []string) {
+	_, height := termbox.Size()
+	y := height - len(lines)
+
+	for i, line := range lines {
+		color := l.Term.Color
+		if i == 0 {
+			color = 3
+		}
+
+		drawLine(l.Term.LeftMargin, y+i, line, color, l.Term.BackgroundColor)
+	}
 }
@@ -5,6 +5,7 @@
 package list
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -22,32 +23,82 @@ func (l *Info) DeleteRune() {
 	l.Keyword = string(sc[:(len(sc) - 1)])
 }
 
-// keyEvent waits for keyboard events.
-func (l *Info) keyEvent() {
+// keyMaps returns the key maps of typing the filter and of the vi normal mode (nil if vi mode is off).
+// The printable keys in the bindings are bound in the normal mode only, so they are still typed into the filter.
+func (l *Info) keyMaps() (insert, normal keyMap) {
+	bindings := insertBindings(l.KeyBindings)
+	if !l.ViMode {
+		return newKeyMap(defaultKeys, bindings), nil
+	}
+
+	// in vi mode, Esc backs to the normal mode when typing the filter.
+	defaults := make(map[string][]string, len(defaultKeys)+1)
+	for action, keys := range defaultKeys {
+		defaults[action] = keys
+	}
+	defaults[ActionExit] = []string{"ctrl+c"}
+	defaults[ActionNormal] = []string{"esc"}
+
+	return newKeyMap(defaults, bindings), newKeyMap(defaultViKeys, l.KeyBindings)
+}
+
+// keyEvent waits for keyboard events, and redraws the list when updated is signaled.
+func (l *Info) keyEvent(updated <-chan struct{}) {
 	l.CursorLine = 0
 	headLine := 2
 
-	_, height := termbox.Size()
-	height -= headLine
-
 	l.Keyword = ""
 	allFlag := false // input Ctrl + A flag
 
+	insertKeys, normalKeys := l.keyMaps()
+	l.normalMode = normalKeys != nil
+
 	l.getFilterText()
 	l.draw()
 
+	events, next := pollEvents()
+	defer close(next)
+
+	polling := true
+
 	for {
-		switch ev := termbox.PollEvent(); ev.Type {
+		if !polling {
+			next <- struct{}{}
+			polling = true
+		}
+
+		var ev termbox.Event
+
+		select {
+		case <-updated:
+			l.draw()
+			continue
+		case ev = <-events:
+			polling = false
+		}
+
+		switch ev.Type {
 		// Type Key
 		case termbox.EventKey:
-			switch ev.Key {
-			// ESC or Ctrl + C Key (Exit)
-			case termbox.KeyEsc, termbox.KeyCtrlC:
+			// any key closes the help window
+			if l.helpLines != nil {
+				l.helpLines = nil
+				l.draw()
+
+				continue
+			}
+
+			keys := insertKeys
+			if l.normalMode {
+				keys = normalKeys
+			}
+
+			switch keys[eventKeyName(ev)] {
+			case ActionExit:
 				termbox.Close()
 				os.Exit(0)
 
-			// AllowUp Key
-			case termbox.KeyArrowUp:
+			case ActionUp:
 				if l.CursorLine > 0 {
 					l.CursorLine--
 				} else { // 掉头到最低行
@@ -56,8 +107,7 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// AllowDown Key
-			case termbox.KeyArrowDown:
+			case ActionDown:
 				if l.CursorLine < len(l.ViewText)-headLine {
 					l.CursorLine++
 				} else { // 掉头到第一行
@@ -66,8 +116,8 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// AllowRight Key
-			case termbox.KeyArrowRight:
+			case ActionPageDown:
+				height := l.listHeight(len(l.previewLines()))
 				nextPosition := ((l.CursorLine + height) / height) * height
 				if nextPosition+2 <= len(l.ViewText) {
 					l.CursorLine = nextPosition
@@ -75,8 +125,8 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// AllowLeft Key
-			case termbox.KeyArrowLeft:
+			case ActionPageUp:
+				height := l.listHeight(len(l.previewLines()))
 				beforePosition := ((l.CursorLine - height) / height) * height
 				if beforePosition >= 0 {
 					l.CursorLine = beforePosition
@@ -84,9 +134,20 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// Tab Key(select)
-			case termbox.KeyTab:
-				if l.MultiFlag {
+			case ActionTop:
+				l.CursorLine = 0
+				l.draw()
+
+			case ActionBottom:
+				l.CursorLine = len(l.ViewText) - headLine
+				if l.CursorLine < 0 {
+					l.CursorLine = 0
+				}
+
+				l.draw()
+
+			case ActionToggle:
+				if l.MultiFlag && l.CursorLine+1 < len(l.ViewText) {
 					l.toggle(strings.Fields(l.ViewText[l.CursorLine+1])[0])
 				}
 
@@ -96,8 +157,8 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// Ctrl + a Key(all select)
-			case termbox.KeyCtrlA:
+			// all select
+			case ActionSelectAll:
 				if l.MultiFlag {
 					l.allToggle(allFlag)
 					// allFlag Toggle
@@ -106,51 +167,60 @@ func (l *Info) keyEvent() {
 
 				l.draw()
 
-			// Ctrl + h Key(Help Window)
-			// case termbox.KeyCtrlH:
+			// invert the selection of the displayed lines
+			case ActionInvert:
+				if l.MultiFlag {
+					l.allToggle(true)
+				}
+
+				l.draw()
+
+			case ActionPreview:
+				l.Preview = !l.Preview
+				l.draw()
+
+			case ActionHelp:
+				l.helpLines = helpLines(keys)
+				l.draw()
+
+			case ActionFilter:
+				l.normalMode = false
+				l.draw()
+
+			case ActionNormal:
+				l.normalMode = true
+				l.draw()
 
-			// Enter Key
-			case termbox.KeyEnter:
+			case ActionEnter:
 				if len(l.SelectName) == 0 {
+					if l.CursorLine+1 >= len(l.ViewText) {
+						continue
+					}
+
 					l.SelectName = append(l.SelectName, strings.Fields(l.ViewText[l.CursorLine+1])[0])
 				}
 
 				return
 
-			// BackSpace Key
-			case termbox.KeyBackspace, termbox.KeyBackspace2:
-				if len(l.Keyword) > 0 {
-					l.DeleteRune()
-					l.getFilterText()
-
-					if l.CursorLine > len(l.ViewText) {
-						l.CursorLine = len(l.ViewText)
-					}
+			case ActionClearFilter:
+				l.Keyword = ""
+				l.refilter(headLine)
+				allFlag = false
 
-					if l.CursorLine < 0 {
-						l.CursorLine = 0
-					}
+				l.draw()
 
+			case ActionBackspace:
+				if len(l.Keyword) > 0 {
+					l.DeleteRune()
+					l.refilter(headLine)
 					allFlag = false
 
 					l.draw()
 				}
 
-			// Space Key
-			case termbox.KeySpace:
-				l.Keyword += " "
-				l.draw()
-
-			// Other Key
+			// not bound key, input the filter
 			default:
-				if ev.Ch != 0 {
-					l.InsertRune(ev.Ch)
-					l.getFilterText()
-
-					if l.CursorLine > len(l.ViewText)-headLine {
-						l.CursorLine = len(l.ViewText) - headLine
-					}
-
+				if !l.normalMode && l.inputFilter(ev, headLine) {
 					allFlag = false
 
 					l.draw()
@@ -162,8 +232,9 @@ func (l *Info) keyEvent() {
 			if ev.Key == termbox.MouseLeft {
 				// mouse select line is (ev.MouseY - headLine) line.
 				mouseSelectLine := ev.MouseY - headLine
+				inList := mouseSelectLine < l.listHeight(len(l.previewLines()))
 
-				if mouseSelectLine <= len(l.ViewText)-headLine {
+				if inList && mouseSelectLine <= len(l.ViewText)-headLine {
 					l.CursorLine = mouseSelectLine
 				}
 
@@ -176,3 +247,64 @@ func (l *Info) keyEvent() {
 		}
 	}
 }
+
+// pollEvents polls the termbox events in a goroutine, the next event is polled only after next is received,
+// and the goroutine exits when next is closed, so no goroutine is left blocked in termbox.PollEvent.
+func pollEvents() (events <-chan termbox.Event, next chan struct{}) {
+	ch := make(chan termbox.Event)
+	next = make(chan struct{})
+
+	go func() {
+		for {
+			ch <- termbox.PollEvent()
+
+			if _, ok := <-next; !ok {
+				return
+			}
+		}
+	}()
+
+	return ch, next
+}
+
+// inputFilter types the char of the key event into the filter, false if it is not a char.
+func (l *Info) inputFilter(ev termbox.Event, headLine int) bool {
+	switch {
+	case ev.Key == termbox.KeySpace:
+		l.Keyword += " "
+	case ev.Ch != 0:
+		l.InsertRune(ev.Ch)
+	default:
+		return false
+	}
+
+	l.refilter(headLine)
+
+	return true
+}
+
+// refilter updates ViewText by the keyword, and keeps the cursor in the list.
+func (l *Info) refilter(headLine int) {
+	l.getFilterText()
+
+	if l.CursorLine > len(l.ViewText)-headLine {
+		l.CursorLine = len(l.ViewText) - headLine
+	}
+
+	if l.CursorLine < 0 {
+		l.CursorLine = 0
+	}
+}
+
+// helpLines returns the help window lines, which lists the active key bindings.
+func helpLines(keys keyMap) []string {
+	lines := []string{"Key bindings (press any key to close)", ""}
+
+	for _, h := range actionHelp {
+		if bound := keys.keys(h.action); len(bound) > 0 {
+			lines = append(lines, fmt.Sprintf("%-28s %s", strings.Join(bound, ", "), h.text))
+		}
+	}
+
+	return lines
+}
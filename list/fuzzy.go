@@ -0,0 +1,289 @@
+package list
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The filter keyword is split by spaces into terms, like fzf, all terms should match:
+//
+//	abc    fuzzy match, the chars appear in order
+//	'abc   exact match, the substring appears
+//	^abc   prefix match
+//	abc$   suffix match
+//	!abc   inverse exact match, also !^abc and !abc$
+//
+// Matching ignores case, and the pinyin initials added in the row text can be matched too.
+
+// score bonuses and penalties of the fuzzy matcher.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 8
+	penaltyGap       = 1
+)
+
+// termKind is the kind of filter term.
+type termKind int
+
+const (
+	termFuzzy termKind = iota
+	termExact
+	termPrefix
+	termSuffix
+	termEqual
+)
+
+// filterTerm is a term of the filter keyword.
+type filterTerm struct {
+	text   []rune
+	kind   termKind
+	negate bool
+}
+
+// parseTerms parses the filter keyword into terms.
+func parseTerms(keyword string) []filterTerm {
+	var terms []filterTerm
+
+	for _, word := range strings.Fields(strings.ToLower(keyword)) {
+		t := filterTerm{kind: termFuzzy}
+
+		if strings.HasPrefix(word, "!") {
+			t.negate, t.kind = true, termExact
+			word = word[1:]
+		}
+
+		switch {
+		case strings.HasPrefix(word, "'"):
+			t.kind, word = termExact, word[1:]
+		case strings.HasPrefix(word, "^") && strings.HasSuffix(word, "$") && len(word) > 1:
+			t.kind, word = termEqual, word[1:len(word)-1]
+		case strings.HasPrefix(word, "^"):
+			t.kind, word = termPrefix, word[1:]
+		case strings.HasSuffix(word, "$"):
+			t.kind, word = termSuffix, word[:len(word)-1]
+		}
+
+		if word == "" {
+			continue
+		}
+
+		t.text = []rune(word)
+		terms = append(terms, t)
+	}
+
+	return terms
+}
+
+// lowerRunes returns the lower case runes of s, and the length without the trailing spaces.
+// It keeps the rune positions as same as []rune(s), for highlighting.
+func lowerRunes(s string) ([]rune, int) {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+
+	n := len(runes)
+	for n > 0 && unicode.IsSpace(runes[n-1]) {
+		n--
+	}
+
+	return runes, n
+}
+
+// matchTerms matches all the terms on the lower case text (with length n without trailing spaces),
+// returns the total score and the matched positions.
+func matchTerms(text []rune, n int, terms []filterTerm) (score int, positions []int, ok bool) {
+	for _, t := range terms {
+		s, pos, matched := t.match(text[:n])
+		if matched == t.negate {
+			return 0, nil, false
+		}
+
+		if !t.negate {
+			score += s
+			positions = append(positions, pos...)
+		}
+	}
+
+	return score, positions, true
+}
+
+// match matches the term on the text.
+func (t filterTerm) match(text []rune) (int, []int, bool) {
+	switch t.kind {
+	case termExact:
+		return exactMatch(text, t.text, 0, len(text))
+	case termPrefix:
+		return exactMatch(text, t.text, 0, len(t.text))
+	case termSuffix:
+		return exactMatch(text, t.text, len(text)-len(t.text), len(text))
+	case termEqual:
+		if len(text) != len(t.text) {
+			return 0, nil, false
+		}
+
+		return exactMatch(text, t.text, 0, len(text))
+	default:
+		return fuzzyMatch(text, t.text)
+	}
+}
+
+// exactMatch finds the best scored occurrence of pattern in text[from:to].
+func exactMatch(text, pattern []rune, from, to int) (int, []int, bool) {
+	if from < 0 || to > len(text) {
+		return 0, nil, false
+	}
+
+	best, bestStart := -1, -1
+
+	for i := from; i+len(pattern) <= to; i++ {
+		if !runesEqual(text[i:i+len(pattern)], pattern) {
+			continue
+		}
+
+		s := len(pattern)*(scoreMatch+bonusConsecutive) + boundaryBonus(text, i)
+		if s > best {
+			best, bestStart = s, i
+		}
+	}
+
+	if bestStart < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, len(pattern))
+	for i := range positions {
+		positions[i] = bestStart + i
+	}
+
+	return best, positions, true
+}
+
+// fuzzyMatch matches the pattern chars in order on the text.
+// Like fzf v1, it finds the first match by a forward scan, then shortens it by a backward scan.
+func fuzzyMatch(text, pattern []rune) (int, []int, bool) {
+	pi, end := 0, -1
+
+	for i, r := range text {
+		if r == pattern[pi] {
+			if pi++; pi == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	start := end
+	for i, pi := end, len(pattern)-1; i >= 0; i-- {
+		if text[i] == pattern[pi] {
+			if pi--; pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	pi = 0
+
+	for i := start; i <= end && pi < len(pattern); i++ {
+		if text[i] != pattern[pi] {
+			continue
+		}
+
+		score += scoreMatch + boundaryBonus(text, i)
+
+		if last := len(positions) - 1; last >= 0 {
+			if gap := i - positions[last] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= gap * penaltyGap
+			}
+		}
+
+		positions = append(positions, i)
+		pi++
+	}
+
+	return score, positions, true
+}
+
+// boundaryBonus returns the bonus if the position i is at the beginning of a word.
+func boundaryBonus(text []rune, i int) int {
+	if i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]) {
+		return bonusBoundary
+	}
+
+	return 0
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fuzzyFilter returns the lines matching the keyword, ordered by the score (stable for the same score).
+func fuzzyFilter(lines []string, lowers [][]rune, keyword string) []string {
+	terms := parseTerms(keyword)
+	if len(terms) == 0 {
+		return lines
+	}
+
+	type scored struct {
+		line  string
+		score int
+	}
+
+	var matches []scored
+
+	for i, line := range lines {
+		lower := lowers[i]
+		n := len(lower)
+		for n > 0 && unicode.IsSpace(lower[n-1]) {
+			n--
+		}
+
+		if score, _, ok := matchTerms(lower, n, terms); ok {
+			matches = append(matches, scored{line: line, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.line
+	}
+
+	return result
+}
+
+// matchPositions returns the sorted rune positions of str matched by the keyword, for highlighting.
+func matchPositions(str, keyword string) []int {
+	terms := parseTerms(keyword)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	lower, n := lowerRunes(str)
+	_, positions, ok := matchTerms(lower, n, terms)
+	if !ok {
+		return nil
+	}
+
+	sort.Ints(positions)
+
+	return positions
+}
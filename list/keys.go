@@ -0,0 +1,232 @@
+package list
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Actions of the list TUI, which can be rebound in [tui.keys] of the config.
+const (
+	ActionUp          = "up"
+	ActionDown        = "down"
+	ActionPageUp      = "pageup"
+	ActionPageDown    = "pagedown"
+	ActionTop         = "top"
+	ActionBottom      = "bottom"
+	ActionToggle      = "toggle"
+	ActionSelectAll   = "selectall"
+	ActionInvert      = "invert"
+	ActionClearFilter = "clearfilter"
+	ActionBackspace   = "backspace"
+	ActionPreview     = "preview"
+	ActionHelp        = "help"
+	ActionEnter       = "enter"
+	ActionExit        = "exit"
+	ActionFilter      = "filter" // vi mode only, back to input the filter
+	ActionNormal      = "normal" // vi mode only, back to the normal mode
+)
+
+// actionHelp is the help text of actions, in the order of the help overlay.
+var actionHelp = []struct{ action, text string }{
+	{ActionUp, "move up"},
+	{ActionDown, "move down"},
+	{ActionPageUp, "previous page"},
+	{ActionPageDown, "next page"},
+	{ActionTop, "go to the first line"},
+	{ActionBottom, "go to the last line"},
+	{ActionToggle, "select/unselect line (multi-select)"},
+	{ActionSelectAll, "select all/toggle all lines (multi-select)"},
+	{ActionInvert, "invert selection (multi-select)"},
+	{ActionClearFilter, "clear the filter"},
+	{ActionBackspace, "delete a filter char"},
+	{ActionPreview, "show/hide preview pane"},
+	{ActionHelp, "show this help"},
+	{ActionEnter, "confirm"},
+	{ActionExit, "exit"},
+	{ActionFilter, "input the filter (vi mode)"},
+	{ActionNormal, "back to normal mode (vi mode)"},
+}
+
+// defaultKeys is the default key bindings when typing the filter.
+var defaultKeys = map[string][]string{
+	ActionUp:          {"up"},
+	ActionDown:        {"down"},
+	ActionPageUp:      {"left", "pgup"},
+	ActionPageDown:    {"right", "pgdn"},
+	ActionTop:         {"home"},
+	ActionBottom:      {"end"},
+	ActionToggle:      {"tab"},
+	ActionSelectAll:   {"ctrl+a"},
+	ActionInvert:      {"ctrl+r"},
+	ActionClearFilter: {"ctrl+u"},
+	ActionBackspace:   {"backspace"},
+	ActionPreview:     {"ctrl+p"},
+	ActionHelp:        {"f1"},
+	ActionEnter:       {"enter"},
+	ActionExit:        {"esc", "ctrl+c"},
+}
+
+// defaultViKeys is the default key bindings of the vi normal mode.
+var defaultViKeys = map[string][]string{
+	ActionUp:          {"up", "k"},
+	ActionDown:        {"down", "j"},
+	ActionPageUp:      {"left", "pgup", "ctrl+b"},
+	ActionPageDown:    {"right", "pgdn", "ctrl+f"},
+	ActionTop:         {"home", "g"},
+	ActionBottom:      {"end", "G"},
+	ActionToggle:      {"tab", "space"},
+	ActionSelectAll:   {"ctrl+a"},
+	ActionInvert:      {"ctrl+r", "v"},
+	ActionClearFilter: {"ctrl+u"},
+	ActionPreview:     {"ctrl+p", "p"},
+	ActionHelp:        {"f1", "?"},
+	ActionEnter:       {"enter"},
+	ActionExit:        {"esc", "ctrl+c", "q"},
+	ActionFilter:      {"/", "i"},
+}
+
+// keyNames is the names of special keys.
+// Notice that termbox can not tell Ctrl+H from Backspace, Ctrl+I from Tab, and Ctrl+M from Enter.
+var keyNames = map[termbox.Key]string{
+	termbox.KeyF1: "f1", termbox.KeyF2: "f2", termbox.KeyF3: "f3", termbox.KeyF4: "f4",
+	termbox.KeyF5: "f5", termbox.KeyF6: "f6", termbox.KeyF7: "f7", termbox.KeyF8: "f8",
+	termbox.KeyF9: "f9", termbox.KeyF10: "f10", termbox.KeyF11: "f11", termbox.KeyF12: "f12",
+	termbox.KeyInsert: "insert", termbox.KeyDelete: "delete",
+	termbox.KeyHome: "home", termbox.KeyEnd: "end", termbox.KeyPgup: "pgup", termbox.KeyPgdn: "pgdn",
+	termbox.KeyArrowUp: "up", termbox.KeyArrowDown: "down", termbox.KeyArrowLeft: "left", termbox.KeyArrowRight: "right",
+	termbox.KeyCtrlSpace: "ctrl+space", termbox.KeyBackspace: "backspace", termbox.KeyBackspace2: "backspace",
+	termbox.KeyTab: "tab", termbox.KeyEnter: "enter", termbox.KeyEsc: "esc", termbox.KeySpace: "space",
+	termbox.KeyCtrlBackslash: "ctrl+\\", termbox.KeyCtrlRsqBracket: "ctrl+]", termbox.KeyCtrl6: "ctrl+^",
+	termbox.KeyCtrlSlash: "ctrl+/",
+}
+
+// keyAliases is the other names of keys.
+var keyAliases = map[string]string{
+	"escape": "esc", "return": "enter", "pageup": "pgup", "pagedown": "pgdn",
+	"ctrl+h": "backspace", "ctrl+i": "tab", "ctrl+m": "enter", "ctrl+[": "esc",
+	"bs": "backspace", "del": "delete", "ctrl+_": "ctrl+/",
+}
+
+// eventKeyName returns the key name of the key event, like "ctrl+a", "tab" or "j".
+func eventKeyName(ev termbox.Event) string {
+	if ev.Ch != 0 {
+		return string(ev.Ch)
+	}
+
+	if name, ok := keyNames[ev.Key]; ok {
+		return name
+	}
+
+	if ev.Key >= termbox.KeyCtrlA && ev.Key <= termbox.KeyCtrlZ {
+		return "ctrl+" + string(rune('a'+ev.Key-termbox.KeyCtrlA))
+	}
+
+	return ""
+}
+
+// normalizeKeyName normalizes the key name in config, like "Ctrl-A" to "ctrl+a".
+// Single char keys are case-sensitive, e.g. "g" and "G" are different keys.
+func normalizeKeyName(key string) string {
+	key = strings.TrimSpace(key)
+	if len([]rune(key)) == 1 {
+		return key
+	}
+
+	key = strings.ToLower(key)
+	if strings.HasPrefix(key, "ctrl-") || strings.HasPrefix(key, "c-") {
+		key = "ctrl+" + key[strings.Index(key, "-")+1:]
+	}
+
+	if alias, ok := keyAliases[key]; ok {
+		return alias
+	}
+
+	return key
+}
+
+// keyMap maps the key names to actions.
+type keyMap map[string]string
+
+// newKeyMap creates the key map from the default bindings, which are overwritten by the actions in bindings.
+// The actions in bindings are case-insensitive, and the ones not in defaults are ignored.
+func newKeyMap(defaults, bindings map[string][]string) keyMap {
+	rebound := make(map[string][]string, len(bindings))
+	for action, keys := range bindings {
+		if action = strings.ToLower(action); defaults[action] != nil {
+			rebound[action] = keys
+		}
+	}
+
+	m := make(keyMap)
+
+	for action, keys := range defaults {
+		if _, ok := rebound[action]; ok {
+			continue
+		}
+
+		m.bind(action, keys)
+	}
+
+	for action, keys := range rebound {
+		m.bind(action, keys)
+	}
+
+	return m
+}
+
+// insertBindings returns the bindings without the printable keys, which are typed into the filter.
+// The actions only bound to printable keys are dropped, so they keep their default keys.
+func insertBindings(bindings map[string][]string) map[string][]string {
+	result := make(map[string][]string, len(bindings))
+
+	for action, keys := range bindings {
+		var kept []string
+
+		for _, key := range keys {
+			if !isPrintableKey(normalizeKeyName(key)) {
+				kept = append(kept, key)
+			}
+		}
+
+		if len(kept) > 0 {
+			result[action] = kept
+		}
+	}
+
+	return result
+}
+
+// isPrintableKey tells if the normalized key name is a printable char, including space.
+func isPrintableKey(key string) bool {
+	if key == "space" {
+		return true
+	}
+
+	r := []rune(key)
+
+	return len(r) == 1 && unicode.IsPrint(r[0])
+}
+
+func (m keyMap) bind(action string, keys []string) {
+	for _, key := range keys {
+		m[normalizeKeyName(key)] = action
+	}
+}
+
+// keys returns the key names bound to action, sorted.
+func (m keyMap) keys(action string) []string {
+	var keys []string
+
+	for key, a := range m {
+		if a == action {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
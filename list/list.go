@@ -8,9 +8,9 @@ package list
 import (
 	"bytes"
 	"fmt"
-	"regexp"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/nsf/termbox-go"
 )
@@ -32,9 +32,25 @@ type Info struct {
 	Title string
 	RowFn func(name string) string
 
+	// PreviewFn returns the detail lines of the named row, shown in the preview pane.
+	PreviewFn func(name string) []string
+	Preview   bool // show preview pane flag
+
+	// ProbeFn probes the reachability of the named row, nil disables the status column.
+	ProbeFn func(name string) (time.Duration, error)
+	probe   *probeState
+
+	// KeyBindings rebinds the actions to keys, like {"toggle": {"tab", "ctrl+t"}}.
+	KeyBindings map[string][]string
+	// ViMode starts the list in the vi normal mode, j/k/g/G moves the cursor, / or i types the filter.
+	ViMode     bool
+	normalMode bool     // in vi normal mode
+	helpLines  []string // help window lines, nil if the help window is closed
+
 	NameList   []string
 	SelectName []string
 	DataText   []string // all data text list
+	lowerData  [][]rune // lower case runes of DataText
 	ViewText   []string // filtered text list
 	MultiFlag  bool     // multi select flag
 	Keyword    string   // input keyword
@@ -145,42 +161,24 @@ func (l *Info) getText() {
 	}
 }
 
-// getFilterText updates l.ViewText with matching keyword (ignore case).
-// DataText sets ViewText if keyword is empty.
+// getFilterText updates l.ViewText with the lines matching keyword (ignore case), ordered by the match score.
+// DataText sets ViewText if keyword is empty. See fuzzy.go for the keyword syntax.
 func (l *Info) getFilterText() {
-	// Initialization ViewText
-	l.ViewText = []string{}
-
-	// SearchText Bounds Space
-	keywords := strings.Fields(l.Keyword)
-	r := l.DataText[1:]
-
-	var tmpText []string
-
-	l.ViewText = append(l.ViewText, l.DataText[0])
-
 	// if No words
-	if len(keywords) == 0 {
+	if len(strings.Fields(l.Keyword)) == 0 {
 		l.ViewText = l.DataText
 		return
 	}
 
-	for i := 0; i < len(keywords); i++ {
-		lowKeyword := regexp.QuoteMeta(strings.ToLower(keywords[i]))
-		re := regexp.MustCompile(lowKeyword)
-		tmpText = []string{}
-
-		for j := 0; j < len(r); j++ {
-			line := r[j]
-			if re.MatchString(strings.ToLower(line)) {
-				tmpText = append(tmpText, line)
-			}
+	// lower case runes of lines are cached, for filtering large lists quickly.
+	if len(l.lowerData) != len(l.DataText) {
+		l.lowerData = make([][]rune, len(l.DataText))
+		for i, line := range l.DataText {
+			l.lowerData[i], _ = lowerRunes(line)
 		}
-
-		r = tmpText
 	}
 
-	l.ViewText = append(l.ViewText, tmpText...)
+	l.ViewText = append([]string{l.DataText[0]}, fuzzyFilter(l.DataText[1:], l.lowerData[1:], l.Keyword)...)
 }
 
 // View displays the list in TUI.
@@ -195,5 +193,9 @@ func (l *Info) View() {
 	termbox.SetInputMode(termbox.InputMouse)
 
 	l.getText()
-	l.keyEvent()
+
+	probeUpdated, stopProbe := l.startProbe()
+	defer stopProbe()
+
+	l.keyEvent(probeUpdated)
 }
@@ -6,8 +6,11 @@ package list
 
 import (
 	"testing"
+	"time"
 
 	"github.com/bingoohuang/bssh/conf"
+	sshcmd "github.com/bingoohuang/bssh/ssh"
+	"github.com/nsf/termbox-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -310,3 +313,206 @@ func TestGetFilterText(t *testing.T) {
 		assert.Equal(t, v.expect, v.l.ViewText, v.desc)
 	}
 }
+
+func TestServerPreview(t *testing.T) {
+	cf := &conf.Config{
+		Server: map[string]conf.ServerConfig{
+			"jump": {User: "user1", Addr: "192.168.101.1"},
+			"web1": {
+				User: "user1", Addr: "192.168.101.2", Port: "2222", Pass: "secret",
+				Keys: []string{"~/.ssh/id_rsa::passphrase"}, Group: []string{"dev/web"},
+				Proxy: "jump", PortForwardLocal: "localhost:8080", PortForwardRemote: "localhost:80",
+				InitialCmd: "cd /app",
+			},
+		},
+		HostInfo: map[string]conf.HostInfo{
+			"web1": {Info: "x86_64 4C", Update: "2026-01-01 10:00:00", LastUsed: "2026-01-02 10:00:00"},
+		},
+	}
+
+	expect := []string{
+		"Connect   : user1@192.168.101.2:2222",
+		"Proxy     : localhost => [ssh://jump:2222] => web1",
+		"Auth      : password, key(~/.ssh/id_rsa)",
+		"Groups    : dev/web",
+		"Forwards  : L local[localhost:8080] => remote[localhost:80]",
+		"InitialCmd: cd /app",
+		"Last Used : 2026-01-02 10:00:00",
+		"Host Info : x86_64 4C (2026-01-01 10:00:00)",
+	}
+	assert.Equal(t, expect, serverPreview(cf, "web1"))
+
+	assert.Equal(t, []string{
+		"Connect   : user1@192.168.101.1",
+		"Proxy     : direct",
+		"Auth      : none",
+	}, serverPreview(cf, "jump"))
+}
+
+func TestParentGroup(t *testing.T) {
+	assert.Equal(t, "prod/web", parentGroup("prod/web/a"))
+	assert.Equal(t, "prod", parentGroup("prod/web"))
+	assert.Equal(t, "", parentGroup("prod"))
+	assert.Equal(t, "", parentGroup(""))
+}
+
+func TestEventKeyName(t *testing.T) {
+	type TestData struct {
+		desc   string
+		ev     termbox.Event
+		expect string
+	}
+
+	tds := []TestData{
+		{desc: "char", ev: termbox.Event{Ch: 'j'}, expect: "j"},
+		{desc: "upper char", ev: termbox.Event{Ch: 'G'}, expect: "G"},
+		{desc: "ctrl key", ev: termbox.Event{Key: termbox.KeyCtrlU}, expect: "ctrl+u"},
+		{desc: "tab", ev: termbox.Event{Key: termbox.KeyTab}, expect: "tab"},
+		{desc: "backspace", ev: termbox.Event{Key: termbox.KeyBackspace2}, expect: "backspace"},
+		{desc: "ctrl+h is backspace", ev: termbox.Event{Key: termbox.KeyCtrlH}, expect: "backspace"},
+		{desc: "arrow", ev: termbox.Event{Key: termbox.KeyArrowLeft}, expect: "left"},
+		{desc: "space", ev: termbox.Event{Key: termbox.KeySpace}, expect: "space"},
+	}
+
+	for _, v := range tds {
+		assert.Equal(t, v.expect, eventKeyName(v.ev), v.desc)
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	m := newKeyMap(defaultKeys, map[string][]string{
+		"Toggle": {"Ctrl-T", "tab"},
+		"top":    {"PageUp"},
+		"Exit":   {"ctrl+q"},
+		"filter": {"/"},
+	})
+
+	assert.Equal(t, ActionToggle, m["ctrl+t"])
+	assert.Equal(t, ActionToggle, m["tab"])
+	assert.Equal(t, ActionTop, m["pgup"])
+	assert.Equal(t, ActionPageUp, m["left"])
+	assert.Equal(t, "", m["home"], "rebound action drops its default keys")
+	assert.Equal(t, []string{"ctrl+t", "tab"}, m.keys(ActionToggle))
+	assert.Equal(t, []string{"ctrl+q"}, m.keys(ActionExit), "mixed case action replaces its default keys")
+	assert.Equal(t, "", m["/"], "action not in defaults is ignored")
+
+	vi := newKeyMap(defaultViKeys, nil)
+	assert.Equal(t, ActionTop, vi["g"])
+	assert.Equal(t, ActionBottom, vi["G"])
+	assert.Equal(t, ActionDown, vi["j"])
+}
+
+func TestKeyMapsPrintable(t *testing.T) {
+	l := &Info{ViMode: true, KeyBindings: map[string][]string{
+		"down": {"J"},
+		"up":   {"K", "ctrl+k"},
+		"top":  {"space"},
+	}}
+
+	insert, normal := l.keyMaps()
+	assert.Equal(t, ActionDown, normal["J"])
+	assert.Equal(t, ActionTop, normal["space"])
+	assert.Equal(t, "", insert["J"], "printable key is not bound when typing the filter")
+	assert.Equal(t, ActionDown, insert["down"], "the default key is kept when only printable keys are rebound")
+	assert.Equal(t, ActionUp, insert["ctrl+k"])
+	assert.Equal(t, "", insert["up"])
+	assert.Equal(t, "", insert["space"])
+
+	// typing the rebound letter reaches the filter
+	l.DataText = []string{"ServerName", "web01", "Jump"}
+	ev := termbox.Event{Type: termbox.EventKey, Ch: 'J'}
+	assert.Equal(t, "", insert[eventKeyName(ev)])
+	assert.True(t, l.inputFilter(ev, 2))
+	assert.Equal(t, "J", l.Keyword)
+	assert.Equal(t, []string{"ServerName", "Jump"}, l.ViewText)
+
+	assert.True(t, l.inputFilter(termbox.Event{Type: termbox.EventKey, Key: termbox.KeySpace}, 2))
+	assert.Equal(t, "J ", l.Keyword)
+	assert.False(t, l.inputFilter(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyF2}, 2))
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	type TestData struct {
+		desc    string
+		keyword string
+		expect  []string
+	}
+
+	lines := []string{
+		"db_master     app@10.0.0.1 # 主库(zk)",
+		"dev_web1      user1@192.168.101.1 # WebServer",
+		"web_prod1     user1@192.168.101.2 # WebServer",
+		"dev_app1      user1@192.168.101.33 # ApplicationServer",
+	}
+
+	tds := []TestData{
+		{desc: "fuzzy", keyword: "dvw", expect: []string{lines[1]}},
+		{desc: "exact", keyword: "'web1", expect: []string{lines[1]}},
+		{desc: "prefix", keyword: "^dev", expect: []string{lines[1], lines[3]}},
+		{desc: "suffix", keyword: "webserver$", expect: []string{lines[1], lines[2]}},
+		{desc: "negate", keyword: "^dev !app", expect: []string{lines[1]}},
+		{desc: "negate prefix", keyword: "!^dev !^db", expect: []string{lines[2]}},
+		{desc: "pinyin initials", keyword: "zk", expect: []string{lines[0]}},
+		{desc: "ignore case", keyword: "APPLICATION", expect: []string{lines[3]}},
+		{desc: "no match", keyword: "xyz", expect: []string{}},
+	}
+
+	for _, v := range tds {
+		l := Info{DataText: append([]string{"ServerName"}, lines...), Keyword: v.keyword}
+		l.getFilterText()
+		assert.Equal(t, v.expect, l.ViewText[1:], v.desc)
+	}
+
+	// consecutive matches at word boundary are ordered first
+	l := Info{DataText: []string{"ServerName", "xaxbxc", "abc_x"}, Keyword: "abc"}
+	l.getFilterText()
+	assert.Equal(t, []string{"ServerName", "abc_x", "xaxbxc"}, l.ViewText)
+}
+
+func TestMatchPositions(t *testing.T) {
+	assert.Equal(t, []int{0, 4, 5}, matchPositions("dev_web1", "dwe"))
+	assert.Equal(t, []int{4, 5, 6}, matchPositions("dev_web1  ", "web"))
+	assert.Equal(t, []int{5, 6, 7}, matchPositions("dev_web1  ", "eb1$"))
+	assert.Nil(t, matchPositions("dev_web1", "!dev"))
+	assert.Nil(t, matchPositions("dev_web1", ""))
+}
+
+func TestProbe(t *testing.T) {
+	assert.Nil(t, serverProbeFn(&conf.Config{Extra: conf.ExtraConfig{Probe: "off"}}))
+	assert.NotNil(t, serverProbeFn(&conf.Config{}))
+
+	probed := make(chan string, 3)
+	l := &Info{NameList: []string{"web01", "web02", "web03"}}
+	l.ProbeFn = func(name string) (time.Duration, error) {
+		probed <- name
+
+		if name == "web02" {
+			return 0, &sshcmd.ViaError{Proxy: "jump"}
+		}
+
+		return time.Millisecond, nil
+	}
+
+	updated, stop := l.startProbe()
+	defer stop()
+
+	// only the drawn rows are probed
+	dot, _, _ := l.probeMark("web01")
+	assert.Equal(t, '○', dot)
+	l.probeMark("web02")
+
+	<-updated
+	assert.ElementsMatch(t, []string{"web01", "web02"}, []string{<-probed, <-probed})
+
+	for {
+		_, _, latency := l.probeMark("web02")
+		if latency != "" {
+			assert.Equal(t, "via jump", latency)
+			break
+		}
+
+		<-updated
+	}
+
+	assert.Len(t, probed, 0)
+}
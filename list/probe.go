@@ -0,0 +1,99 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sshcmd "github.com/bingoohuang/bssh/ssh"
+)
+
+// probeWorkers is the max count of concurrent probes.
+const probeWorkers = 16
+
+// probeState holds the reachability of rows, updated by probe goroutines.
+type probeState struct {
+	mu     sync.Mutex
+	status map[string]probeStatus
+	// names queues the rows to probe, a row is queued once when it is drawn first
+	names chan string
+}
+
+// probeStatus is the reachability of a row.
+type probeStatus struct {
+	done    bool
+	latency time.Duration
+	err     error
+}
+
+// startProbe starts the probe workers, which probe the rows by l.ProbeFn when they are visible.
+// The updated channel is signaled when a probe finishes, nil if probing is off,
+// and the stop function stops the probes not started yet.
+func (l *Info) startProbe() (updated <-chan struct{}, stop func()) {
+	if l.ProbeFn == nil {
+		return nil, func() {}
+	}
+
+	state := &probeState{status: make(map[string]probeStatus), names: make(chan string, len(l.NameList))}
+	l.probe = state
+
+	done := make(chan struct{})
+	signal := make(chan struct{}, 1)
+
+	for i := 0; i < probeWorkers && i < len(l.NameList); i++ {
+		go func() {
+			for {
+				var name string
+
+				select {
+				case <-done:
+					return
+				case name = <-state.names:
+				}
+
+				latency, err := l.ProbeFn(name)
+
+				state.mu.Lock()
+				state.status[name] = probeStatus{done: true, latency: latency, err: err}
+				state.mu.Unlock()
+
+				select {
+				case signal <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	var once sync.Once
+	return signal, func() { once.Do(func() { close(done) }) }
+}
+
+// probeMark returns the status dot, its color and the latency text of the named row,
+// the row not probed yet is queued to probe.
+func (l *Info) probeMark(name string) (dot rune, color int, latency string) {
+	l.probe.mu.Lock()
+	status, ok := l.probe.status[name]
+	if !ok {
+		select {
+		case l.probe.names <- name:
+			l.probe.status[name] = probeStatus{}
+		default:
+		}
+	}
+	l.probe.mu.Unlock()
+
+	var via *sshcmd.ViaError
+
+	switch {
+	case !status.done:
+		return '○', 7, ""
+	case errors.As(status.err, &via):
+		return '○', 3, "via " + via.Proxy
+	case status.err != nil:
+		return '●', 1, "down"
+	default:
+		return '●', 2, fmt.Sprintf("%dms", status.latency.Milliseconds())
+	}
+}
@@ -0,0 +1,375 @@
+// Package logs indexes, searches and replays the terminal logs written by the shell sessions.
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/lunixbochs/vtclean"
+)
+
+// Log formats of the entries.
+const (
+	FormatText      = "text"
+	FormatAsciicast = "asciicast"
+)
+
+// Entry is a terminal log file.
+type Entry struct {
+	Path    string
+	Server  string
+	Run     string    // the mode and run id of cmd and pshell logs, like cmd-150405-ab3d.
+	Time    time.Time // start time of the session, or the modify time if unknown.
+	ModTime time.Time
+	Size    int64
+	Format  string
+}
+
+// Filter selects the log entries.
+type Filter struct {
+	// Server is the server name or glob pattern, like web*.
+	Server string
+	// Since selects the logs written after the time.
+	Since time.Time
+	// Run selects the logs of the cmd and pshell run, like 150405-ab3d.
+	Run string
+}
+
+// Match reports whether the entry is selected by the filter.
+func (f Filter) Match(e Entry) bool {
+	if f.Server != "" && e.Server != f.Server {
+		if ok, _ := path.Match(f.Server, e.Server); !ok {
+			return false
+		}
+	}
+
+	if f.Run != "" && !strings.Contains(e.Run, f.Run) {
+		return false
+	}
+
+	return f.Since.IsZero() || !e.ModTime.Before(f.Since)
+}
+
+// BaseDir returns the fixed directory part of the log dirpath template, like
+// /path/to for /path/to/<Date>_<ServerName>/logdir.
+func BaseDir(dirTemplate string) string {
+	dir := ss.ExpandHome(dirTemplate)
+	idx := strings.Index(dir, "<")
+	if idx < 0 {
+		return filepath.Clean(dir)
+	}
+
+	if base := dir[:idx]; strings.HasSuffix(base, string(filepath.Separator)) {
+		return filepath.Clean(base)
+	}
+
+	return filepath.Dir(dir[:idx])
+}
+
+// dirVarReg matches the variables of the log dirpath template.
+var dirVarReg = regexp.MustCompile(`<Date>|<ServerName>|<RunID>`)
+
+// dirPattern returns the regexp to parse the <Date>, <ServerName> and <RunID> from the log directory.
+func dirPattern(dirTemplate string) *regexp.Regexp {
+	dir := filepath.Clean(ss.ExpandHome(dirTemplate))
+	var b strings.Builder
+
+	last := 0
+	for _, m := range dirVarReg.FindAllStringIndex(dir, -1) {
+		b.WriteString(regexp.QuoteMeta(dir[last:m[0]]))
+		switch dir[m[0]:m[1]] {
+		case "<Date>":
+			b.WriteString(`(?P<date>\d{8})`)
+		case "<ServerName>":
+			b.WriteString(`(?P<server>[^/\\]+?)`)
+		default:
+			b.WriteString(`(?P<run>[\w-]+)`)
+		}
+		last = m[1]
+	}
+
+	b.WriteString(regexp.QuoteMeta(dir[last:]))
+
+	return regexp.MustCompile("^" + b.String() + "$")
+}
+
+// fileNameReg matches the log file names, like 20240102_web01.log, 20240102_150405_web01.cast
+// or 20240102_web01@cmd-150405-ab3d.log, the date and server name parts are omitted when they are in the directory.
+var fileNameReg = regexp.MustCompile(`^(?:(\d{8})_?)?(?:(\d{6})_?)?([^@]*?)(?:@([\w-]+))?\.(log|cast)$`)
+
+// parseEntry parses the server name and start time from the log file path.
+func parseEntry(dirReg *regexp.Regexp, file string, info os.FileInfo) (Entry, bool) {
+	m := fileNameReg.FindStringSubmatch(filepath.Base(file))
+	if m == nil {
+		return Entry{}, false
+	}
+
+	e := Entry{
+		Path: file, Server: m[3], Run: m[4], ModTime: info.ModTime(), Size: info.Size(),
+		Format: ss.If(m[5] == "cast", FormatAsciicast, FormatText),
+	}
+	date, clock := m[1], m[2]
+
+	if dm := dirReg.FindStringSubmatch(filepath.Dir(file)); dm != nil {
+		for i, name := range dirReg.SubexpNames() {
+			switch {
+			case name == "date" && date == "":
+				date = dm[i]
+			case name == "server" && e.Server == "":
+				e.Server = dm[i]
+			case name == "run" && e.Run == "":
+				e.Run = dm[i]
+			}
+		}
+	}
+
+	e.Time = e.ModTime
+	if date != "" {
+		if t, err := time.ParseInLocation("20060102150405", date+ss.Or(clock, "000000"), time.Local); err == nil {
+			e.Time = t
+		}
+	}
+
+	return e, e.Server != ""
+}
+
+// Find returns the log entries under the log dirpath template, ordered by the start time.
+func Find(dirTemplate string, filter Filter) ([]Entry, error) {
+	if dirTemplate == "" {
+		return nil, errors.New("log dirpath is not set in the config [log] section")
+	}
+
+	dirReg := dirPattern(dirTemplate)
+	var entries []Entry
+
+	err := filepath.Walk(BaseDir(dirTemplate), func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if e, ok := parseEntry(dirReg, file, info); ok && filter.Match(e) {
+			entries = append(entries, e)
+		}
+
+		return nil
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	return entries, err
+}
+
+// ParseSince parses the --since value, a duration like 30m, 12h, 2d, 1w, or a date like 2006-01-02 [15:04].
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if n := len(s) - 1; n > 0 && (s[n] == 'd' || s[n] == 'w') {
+		if v, err := strconv.Atoi(s[:n]); err == nil {
+			days := v * ss.If(s[n] == 'w', 7, 1)
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", "20060102"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid since %q, use a duration like 2d, 12h or a date like 2006-01-02", s)
+}
+
+// PrintList prints the entries as a table.
+func PrintList(w io.Writer, entries []Entry) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"#", "Start", "Server", "Run", "Format", "Size", "Path"})
+
+	for i, e := range entries {
+		t.AppendRow(table.Row{i + 1, e.Time.Format("2006-01-02 15:04:05"), e.Server, e.Run, e.Format, humanSize(e.Size), e.Path})
+	}
+
+	t.Render()
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Grep prints the lines matching reg of the entries, like path:line: text,
+// and path@mm:ss: text for asciicast logs, the offset can be used to seek in replay.
+func Grep(w io.Writer, entries []Entry, reg *regexp.Regexp) (found bool, err error) {
+	for _, e := range entries {
+		err := eachLine(e, func(pos string, line string) {
+			if reg.MatchString(line) {
+				found = true
+				fmt.Fprintf(w, "%s%s: %s\n", e.Path, pos, line)
+			}
+		})
+		if err != nil {
+			return found, err
+		}
+	}
+
+	return found, nil
+}
+
+// eachLine calls fn with the ansi-cleaned lines of the log.
+func eachLine(e Entry, fn func(pos, line string)) error {
+	if e.Format != FormatAsciicast {
+		f, err := os.Open(e.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for n := 1; scanner.Scan(); n++ {
+			fn(":"+strconv.Itoa(n), cleanLine(scanner.Text()))
+		}
+
+		return scanner.Err()
+	}
+
+	frames, err := LoadFrames(e.Path)
+	if err != nil {
+		return err
+	}
+
+	var line strings.Builder
+	var at float64
+	for _, f := range frames {
+		for _, s := range strings.SplitAfter(f.Data, "\n") {
+			if line.Len() == 0 {
+				at = f.At
+			}
+
+			line.WriteString(s)
+			if strings.HasSuffix(s, "\n") {
+				fn("@"+formatOffset(at), cleanLine(line.String()))
+				line.Reset()
+			}
+		}
+	}
+
+	if line.Len() > 0 {
+		fn("@"+formatOffset(at), cleanLine(line.String()))
+	}
+
+	return nil
+}
+
+func cleanLine(s string) string {
+	return vtclean.Clean(strings.TrimRight(s, "\r\n"), false)
+}
+
+func formatOffset(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// Show writes the log content to w, the output of the asciicast log is written as is.
+func Show(w io.Writer, e Entry) error {
+	if e.Format != FormatAsciicast {
+		f, err := os.Open(e.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(w, f)
+		return err
+	}
+
+	frames, err := LoadFrames(e.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range frames {
+		if _, err := io.WriteString(w, f.Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the entry of the log file path, or the latest log of the server name.
+func Resolve(dirTemplate, arg string) (Entry, error) {
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		e, ok := parseEntry(dirPattern(dirTemplate), arg, info)
+		if !ok {
+			e = Entry{Path: arg, Time: info.ModTime(), ModTime: info.ModTime(), Size: info.Size()}
+			e.Format = ss.If(strings.HasSuffix(arg, ".cast") || isCast(arg), FormatAsciicast, FormatText)
+		}
+		return e, nil
+	}
+
+	entries, err := Find(dirTemplate, Filter{Server: arg})
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("no log file or server logs found: %s", arg)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ModTime.Before(entries[j].ModTime) })
+
+	return entries[len(entries)-1], nil
+}
+
+// isCast reports whether the file starts with an asciicast v2 header.
+func isCast(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadBytes('\n')
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	return json.Unmarshal(line, &header) == nil && header.Version == 2
+}
@@ -0,0 +1,81 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind(t *testing.T) {
+	base := t.TempDir()
+	write := func(name, content string) {
+		file := filepath.Join(base, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.Nil(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	write("20240102_web01/20240102_web01.log", "hello\n")
+	write("20240103_db01/20240103_101112.cast", `{"version": 2, "width": 80, "height": 24}`+"\n"+`[0.5, "o", "select 1;\r\n"]`+"\n")
+	write("20240103_db01/notes.txt", "ignored")
+	write("20240104_combined/@cmd-150405-ab3d.log", "[db01] ok\n")
+
+	entries, err := Find(filepath.Join(base, "<Date>_<ServerName>"), Filter{})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "combined", entries[2].Server)
+	assert.Equal(t, "cmd-150405-ab3d", entries[2].Run)
+
+	entries, _ = Find(filepath.Join(base, "<Date>_<ServerName>"), Filter{Run: "150405-ab3d"})
+	assert.Len(t, entries, 1)
+
+	entries, _ = Find(filepath.Join(base, "<Date>_<ServerName>"), Filter{})
+	assert.Equal(t, "web01", entries[0].Server)
+	assert.Equal(t, FormatText, entries[0].Format)
+	assert.Equal(t, "db01", entries[1].Server)
+	assert.Equal(t, FormatAsciicast, entries[1].Format)
+	assert.Equal(t, time.Date(2024, 1, 3, 10, 11, 12, 0, time.Local), entries[1].Time)
+
+	entries, _ = Find(filepath.Join(base, "<Date>_<ServerName>"), Filter{Server: "db*"})
+	assert.Len(t, entries, 1)
+
+	var out bytes.Buffer
+	found, err := Grep(&out, entries, regexp.MustCompile("select"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, entries[0].Path+"@00:00: select 1;\n", out.String())
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	for s, want := range map[string]time.Time{
+		"2d":         now.AddDate(0, 0, -2),
+		"1w":         now.AddDate(0, 0, -7),
+		"90m":        now.Add(-90 * time.Minute),
+		"2024-01-02": time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local),
+	} {
+		got, err := ParseSince(s, now)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	_, err := ParseSince("yesterday", now)
+	assert.NotNil(t, err)
+}
+
+func TestPlayerSeek(t *testing.T) {
+	var out bytes.Buffer
+	p := &Player{Frames: []Frame{{0, "a"}, {3, "b"}, {8, "c"}}, Out: &out}
+
+	p.Seek(5)
+	assert.Equal(t, "ab", out.String())
+
+	p.Seek(1)
+	assert.Equal(t, "ab\x1bca", out.String())
+	assert.Equal(t, 1, p.next)
+}
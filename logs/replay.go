@@ -0,0 +1,244 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Frame is an output of the recorded session, At is the offset in seconds from the session start.
+type Frame struct {
+	At   float64
+	Data string
+}
+
+// textTimestampLayout is the line head timestamp of the text log, written when [log] timestamp = true.
+const textTimestampLayout = "2006/01/02 15:04:05 "
+
+// LoadFrames loads the output frames of the log file.
+// The text log lines are timed by the line head timestamps, or all at 0 without timestamps.
+func LoadFrames(file string) ([]Frame, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	first, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	if json.Unmarshal([]byte(first), &header) == nil && header.Version == 2 {
+		return readCastFrames(r)
+	}
+
+	return readTextFrames(io.MultiReader(strings.NewReader(first), r))
+}
+
+// readCastFrames reads the output events of asciicast v2, like [1.001, "o", "data"].
+func readCastFrames(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for n := 2; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event []any
+		if err := json.Unmarshal([]byte(line), &event); err != nil || len(event) != 3 {
+			return frames, fmt.Errorf("invalid asciicast event at line %d: %s", n, line)
+		}
+
+		at, _ := event[0].(float64)
+		code, _ := event[1].(string)
+		data, _ := event[2].(string)
+
+		if code == "o" {
+			frames = append(frames, Frame{At: at, Data: data})
+		}
+	}
+
+	return frames, scanner.Err()
+}
+
+func readTextFrames(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+	var start time.Time
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			f := Frame{Data: line}
+			if len(frames) > 0 {
+				f.At = frames[len(frames)-1].At
+			}
+
+			if len(line) >= len(textTimestampLayout) {
+				if t, e := time.ParseInLocation(textTimestampLayout, line[:len(textTimestampLayout)], time.Local); e == nil {
+					if start.IsZero() {
+						start = t
+					}
+
+					f.At, f.Data = t.Sub(start).Seconds(), line[len(textTimestampLayout):]
+				}
+			}
+
+			// the terminal is in raw mode when replaying.
+			f.Data = strings.TrimRight(f.Data, "\r\n") + "\r\n"
+			frames = append(frames, f)
+		}
+
+		if err == io.EOF {
+			return frames, nil
+		}
+
+		if err != nil {
+			return frames, err
+		}
+	}
+}
+
+// seekStep is the seconds to seek by the arrow keys.
+const seekStep = 5.0
+
+// Player plays the frames in real time.
+//
+//	space     pause/resume
+//	+ / -     speed up / slow down
+//	→ / l     seek forward 5s
+//	← / h     seek backward 5s
+//	q, ctrl+c quit
+type Player struct {
+	Frames []Frame
+	Out    io.Writer
+	// Speed is the playback speed, 2 plays twice as fast.
+	Speed float64
+	// Idle limits the idle time between the frames, in seconds, 0 is unlimited.
+	Idle float64
+	// Keys is the input key presses, nil to play without controls.
+	Keys <-chan string
+
+	next   int     // the next frame to write.
+	pos    float64 // the current position in seconds.
+	paused bool
+}
+
+// Play plays the frames until the end or quit.
+func (p *Player) Play() {
+	if p.Speed <= 0 {
+		p.Speed = 1
+	}
+
+	for p.next < len(p.Frames) {
+		if p.paused {
+			if !p.handleKey(<-p.Keys) {
+				return
+			}
+			continue
+		}
+
+		gap := p.Frames[p.next].At - p.pos
+		if p.Idle > 0 && gap > p.Idle {
+			p.pos, gap = p.Frames[p.next].At-p.Idle, p.Idle
+		}
+
+		start := time.Now()
+		timer := time.NewTimer(time.Duration(gap / p.Speed * float64(time.Second)))
+
+		select {
+		case <-timer.C:
+			p.write(p.Frames[p.next])
+		case key := <-p.Keys:
+			timer.Stop()
+			p.pos = min(p.pos+time.Since(start).Seconds()*p.Speed, p.Frames[p.next].At)
+			if !p.handleKey(key) {
+				return
+			}
+		}
+	}
+}
+
+func (p *Player) write(f Frame) {
+	_, _ = io.WriteString(p.Out, f.Data)
+	p.pos = max(p.pos, f.At)
+	p.next++
+}
+
+// handleKey handles the key press, returns false to quit.
+func (p *Player) handleKey(key string) bool {
+	switch key {
+	case "q", "Q", "\x03":
+		return false
+	case " ":
+		p.paused = !p.paused
+	case "+", "=":
+		p.Speed = min(p.Speed*2, 64)
+	case "-", "_":
+		p.Speed = max(p.Speed/2, 1.0/64)
+	case "l", "\x1b[C":
+		p.Seek(p.pos + seekStep)
+	case "h", "\x1b[D":
+		p.Seek(p.pos - seekStep)
+	}
+
+	return true
+}
+
+// Seek moves the position to at seconds, the frames before it are written immediately.
+// Seeking backward resets the terminal and redraws from the beginning.
+func (p *Player) Seek(at float64) {
+	at = max(at, 0)
+
+	if at < p.pos {
+		_, _ = io.WriteString(p.Out, "\x1bc")
+		p.next, p.pos = 0, 0
+	}
+
+	for p.next < len(p.Frames) && p.Frames[p.next].At <= at {
+		p.write(p.Frames[p.next])
+	}
+
+	p.pos = at
+}
+
+// ReadKeys reads the key presses from r, the escape sequences of arrow keys are kept as one key.
+func ReadKeys(r io.Reader) <-chan string {
+	keys := make(chan string)
+
+	go func() {
+		buf := make([]byte, 32)
+		for {
+			n, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+
+			for s := string(buf[:n]); s != ""; {
+				k := s[:1]
+				if strings.HasPrefix(s, "\x1b[") && len(s) >= 3 {
+					k = s[:3]
+				}
+
+				keys <- k
+				s = s[len(k):]
+			}
+		}
+	}()
+
+	return keys
+}
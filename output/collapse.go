@@ -0,0 +1,142 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Group is the hosts with the identical output of a command.
+type Group struct {
+	Servers []string
+	Result  string
+}
+
+// GroupOutputs groups the servers by the identical result, the largest group first,
+// ties are ordered by the first server in servers.
+func GroupOutputs(servers []string, results map[string]string) (groups []*Group) {
+	index := map[string]*Group{}
+
+	for _, server := range servers {
+		result, ok := results[server]
+		if !ok {
+			continue
+		}
+
+		g, ok := index[result]
+		if !ok {
+			g = &Group{Result: result}
+			index[result] = g
+			groups = append(groups, g)
+		}
+
+		g.Servers = append(g.Servers, server)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Servers) > len(groups[j].Servers) })
+
+	return groups
+}
+
+// PrintCollapsed prints each distinct output of the hosts once, headed by the compact host list,
+// like dshbak -c.
+func PrintCollapsed(w io.Writer, servers []string, results map[string]string) {
+	for _, g := range GroupOutputs(servers, results) {
+		fmt.Fprintf(w, "----- %s (%d)\n", CompactHosts(g.Servers), len(g.Servers))
+		fmt.Fprint(w, g.Result)
+
+		if g.Result != "" && !strings.HasSuffix(g.Result, "\n") {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// hostNum is the last number in the first label of a host name, like web{01}.dc1.
+type hostNum struct {
+	digits string
+	num    int
+}
+
+// CompactHosts folds the host names with the numbers into the ranges,
+// like web01,web02,web03,web05 to web[01-03,05], by the last number in the first label.
+// The names are kept in the order of their first prefix.
+func CompactHosts(names []string) string {
+	var keys []string
+
+	prefixes := map[string]string{}
+	suffixes := map[string]string{}
+	nums := map[string][]hostNum{}
+
+	for _, name := range names {
+		label, _, _ := strings.Cut(name, ".")
+		end := strings.LastIndexAny(label, "0123456789") + 1
+		start := end
+		for start > 0 && name[start-1] >= '0' && name[start-1] <= '9' {
+			start--
+		}
+
+		n, err := strconv.Atoi(name[start:end])
+		if end == 0 || err != nil {
+			keys = append(keys, name)
+			prefixes[name] = name
+			continue
+		}
+
+		key := name[:start] + "\x00" + name[end:]
+		if _, ok := nums[key]; !ok {
+			keys = append(keys, key)
+			prefixes[key], suffixes[key] = name[:start], name[end:]
+		}
+
+		nums[key] = append(nums[key], hostNum{digits: name[start:end], num: n})
+	}
+
+	items := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		hs, ok := nums[key]
+		if !ok {
+			items = append(items, prefixes[key])
+			continue
+		}
+
+		if len(hs) == 1 {
+			items = append(items, prefixes[key]+hs[0].digits+suffixes[key])
+			continue
+		}
+
+		items = append(items, prefixes[key]+"["+compactNums(hs)+"]"+suffixes[key])
+	}
+
+	return strings.Join(items, ",")
+}
+
+// compactNums joins the numbers into the ranges, the zero padded numbers are
+// consecutive only if they are the same width.
+func compactNums(hs []hostNum) string {
+	sort.SliceStable(hs, func(i, j int) bool { return hs[i].num < hs[j].num })
+
+	padded := func(h hostNum) bool { return len(h.digits) > 1 && h.digits[0] == '0' }
+
+	var ranges []string
+
+	for i := 0; i < len(hs); {
+		j := i
+		for j+1 < len(hs) && hs[j+1].num == hs[j].num+1 &&
+			(len(hs[j+1].digits) == len(hs[j].digits) || !padded(hs[j]) && !padded(hs[j+1])) {
+			j++
+		}
+
+		if i == j {
+			ranges = append(ranges, hs[i].digits)
+		} else {
+			ranges = append(ranges, hs[i].digits+"-"+hs[j].digits)
+		}
+
+		i = j + 1
+	}
+
+	return strings.Join(ranges, ",")
+}
@@ -0,0 +1,23 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactHosts(t *testing.T) {
+	assert.Equal(t, "web[01-03,05]", CompactHosts([]string{"web01", "web02", "web03", "web05"}))
+	assert.Equal(t, "web[8-11]", CompactHosts([]string{"web10", "web8", "web9", "web11"}))
+	assert.Equal(t, "web[09-10],app[9,010]", CompactHosts([]string{"web09", "web10", "app9", "app010"}))
+	assert.Equal(t, "db1,node[1-2].dc1,gateway", CompactHosts([]string{"db1", "node1.dc1", "gateway", "node2.dc1"}))
+}
+
+func TestPrintCollapsed(t *testing.T) {
+	var b strings.Builder
+	PrintCollapsed(&b, []string{"web01", "web02", "web03"}, map[string]string{
+		"web01": "5.10\n", "web02": "5.4", "web03": "5.10\n",
+	})
+	assert.Equal(t, "----- web[01,03] (2)\n5.10\n----- web02 (1)\n5.4\n", b.String())
+}
@@ -12,13 +12,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/conf"
-	"github.com/vbauerster/mpb"
-	"github.com/vbauerster/mpb/decor"
 )
 
 // Output struct. command execute and bssh-shell mode output data.
@@ -56,8 +53,7 @@ type Output struct {
 
 	// Progress bar
 	// TDXX(blacknon): プログレスバーを出力させるための項目を追加
-	Progress   *mpb.Progress
-	ProgressWG *sync.WaitGroup
+	Progress *Progress
 
 	// Enable/Disable print header
 	EnableHeader  bool
@@ -78,8 +74,11 @@ func (o *Output) Create(server string) {
 	addL := length - len(server)
 
 	// get color num
-	n := common.GetOrderNumber(server, o.ServerList)
-	colorServerName := OutColorStrings(n, server)
+	colorServerName := server
+	if o.AutoColor {
+		n := common.GetOrderNumber(server, o.ServerList)
+		colorServerName = OutColorStrings(n, server)
+	}
 
 	// set templete
 	p := o.Templete
@@ -136,57 +135,31 @@ func (o *Output) Printer(reader io.Reader) {
 	}
 }
 
-// ProgressPrinter ...
-func (o *Output) ProgressPrinter(size int64, reader io.Reader, path string) error {
+// ProgressPrinter reads the reader to EOF, and prints the progress of the transfer of path by the Progress.
+func (o *Output) ProgressPrinter(size int64, reader io.Reader, path string) (err error) {
 	// print header
 	oPrompt := ""
-	name := decor.Name(oPrompt)
-
 	if len(o.ServerList) > 1 {
 		oPrompt = o.GetPrompt()
-		name = decor.Name(oPrompt, decor.WC{W: len(path) + 1, C: decor.DSyncWidth})
 	}
 
-	// trim space
-	path = strings.TrimSpace(path)
-
-	// set progress
-	bar := o.Progress.AddBar(size,
-		mpb.BarClearOnComplete(),
-		mpb.PrependDecorators(name,
-			decor.OnComplete(decor.Name(path, decor.WCSyncSpaceR), fmt.Sprintf("%s done!", path)),
-		),
-		mpb.AppendDecorators(
-			decor.OnComplete(decor.Percentage(decor.WC{W: 5}), ""),
-			decor.Elapsed(decor.ET_STYLE_HHMMSS, decor.WC{W: 10}),
-		),
-	)
-
-	sum := 0
-	startTime := time.Now()
+	bar := o.Progress.Bar(o.Server, oPrompt, strings.TrimSpace(path), size)
+	defer func() { bar.Finish(err) }()
 
-	// print out progress
-	defer o.ProgressWG.Done()
+	// read byte (1mb)
+	b := make([]byte, 1048576)
 
 	for {
-		// read byte (1mb)
-		b := make([]byte, 1048576)
 		s, err := reader.Read(b)
-
-		sum += s
-
-		bar.IncrBy(s, time.Since(startTime))
+		bar.Add(s)
 
 		// check exit
 		if err == io.EOF {
-			bar.SetTotal(size, true)
-			break
+			return nil
 		} else if err != nil {
 			return err
 		}
 	}
-
-	return nil
 }
 
 // OutColorStrings ...
@@ -0,0 +1,351 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/cheggaaa/pb/v3"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+// Bar is the progress of a transfer, the mpb bar of a file, the row of a host in the aggregate mode,
+// or the cheggaaa/pb bar of the in-band transfers.
+type Bar interface {
+	// Add counts the transferred bytes.
+	Add(n int)
+	// Finish completes the transfer, err is nil if succeeded.
+	Finish(err error)
+}
+
+// Progress creates the bars of the transfers of the hosts, a bar per file, or a row per host if Aggregate,
+// and records the stats of the hosts for the summary.
+type Progress struct {
+	// Aggregate shows a row per host, files done/total, bytes, speed, ETA and errors, instead of a bar per file.
+	Aggregate bool
+
+	mpb *mpb.Progress
+	wg  *sync.WaitGroup
+
+	// mu guards the stats, rowMu guards the rows of the hosts,
+	// mu is never held on calling mpb, for the host rows lock mu on rendering.
+	mu    sync.Mutex
+	rowMu sync.Mutex
+	hosts map[string]*HostStat
+	order []string
+}
+
+// HostStat is the stats of the transfers of a host.
+type HostStat struct {
+	Host   string
+	Files  int   // files started or planned
+	Done   int   // files transferred
+	Total  int64 // bytes started or planned
+	Bytes  int64 // bytes transferred
+	Errors []string
+	Start  time.Time
+	End    time.Time
+
+	started int // files started
+	planned int // files planned
+	row     *mpb.Bar
+}
+
+// NewProgress creates the Progress writing to w.
+func NewProgress(aggregate bool, w io.Writer) *Progress {
+	wg := new(sync.WaitGroup)
+
+	return &Progress{
+		Aggregate: aggregate,
+		mpb:       mpb.New(mpb.WithWaitGroup(wg), mpb.WithOutput(w)),
+		wg:        wg,
+		hosts:     map[string]*HostStat{},
+	}
+}
+
+// host returns the stat of the host, p.mu should be locked.
+func (p *Progress) host(name string) *HostStat {
+	st, ok := p.hosts[name]
+	if !ok {
+		st = &HostStat{Host: name}
+		p.hosts[name] = st
+		p.order = append(p.order, name)
+	}
+
+	return st
+}
+
+// Plan adds the files and bytes to transfer to the host, for the totals and ETA of the host row.
+func (p *Progress) Plan(host string, files int, size int64) {
+	p.mu.Lock()
+
+	st := p.host(host)
+	st.planned += files
+	st.Files += files
+	st.Total += size
+	p.mu.Unlock()
+
+	p.updateRow(st, "")
+}
+
+// Fail records the failure of the transfer of name to the host.
+func (p *Progress) Fail(host, name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.host(host)
+	st.Errors = append(st.Errors, name+": "+err.Error())
+	st.End = time.Now()
+}
+
+// Bar creates the bar of the transfer of size bytes of the file name to the host, prompt is printed before name.
+func (p *Progress) Bar(host, prompt, name string, size int64) Bar {
+	p.mu.Lock()
+	st := p.host(host)
+	if st.Start.IsZero() {
+		st.Start = time.Now()
+	}
+
+	if st.started++; st.started > st.planned {
+		st.Files++
+		st.Total += size
+	}
+	p.mu.Unlock()
+
+	if p.Aggregate {
+		p.updateRow(st, prompt)
+		return &hostBar{p: p, st: st}
+	}
+
+	nameDecor := decor.Name(prompt)
+	if prompt != "" {
+		nameDecor = decor.Name(prompt, decor.WC{W: len(name) + 1, C: decor.DSyncWidth})
+	}
+
+	p.wg.Add(1)
+	bar := p.mpb.AddBar(size,
+		mpb.BarClearOnComplete(),
+		mpb.PrependDecorators(nameDecor,
+			decor.OnComplete(decor.Name(name, decor.WCSyncSpaceR), fmt.Sprintf("%s done!", name)),
+		),
+		mpb.AppendDecorators(
+			decor.OnComplete(decor.Percentage(decor.WC{W: 5}), ""),
+			decor.Elapsed(decor.ET_STYLE_HHMMSS, decor.WC{W: 10}),
+		),
+	)
+
+	return &fileBar{p: p, st: st, bar: bar, size: size, start: time.Now()}
+}
+
+// updateRow creates or updates the total of the row of the host in the aggregate mode.
+func (p *Progress) updateRow(st *HostStat, prompt string) {
+	if !p.Aggregate {
+		return
+	}
+
+	p.rowMu.Lock()
+	defer p.rowMu.Unlock()
+
+	p.mu.Lock()
+	total := st.Total
+	p.mu.Unlock()
+
+	// the extra byte keeps the row from completing before Wait, while the total grows by the started files
+	if st.row != nil {
+		st.row.SetTotal(total+1, false)
+		return
+	}
+
+	p.wg.Add(1)
+	st.row = p.mpb.AddBar(total+1,
+		mpb.PrependDecorators(decor.Name(ss.Or(prompt, st.Host), decor.WCSyncSpaceR)),
+		mpb.AppendDecorators(&hostDecorator{WC: initWC(decor.WCSyncWidthR), p: p, st: st}),
+	)
+}
+
+func initWC(wc decor.WC) decor.WC {
+	wc.Init()
+	return wc
+}
+
+// add counts the transferred bytes of the host.
+func (p *Progress) add(st *HostStat, n int) {
+	p.mu.Lock()
+	st.Bytes += int64(n)
+	p.mu.Unlock()
+}
+
+// finish counts the done file of the host.
+func (p *Progress) finish(st *HostStat, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		st.Done++
+	}
+
+	st.End = time.Now()
+}
+
+// Wait completes the host rows, and waits the bars to complete.
+func (p *Progress) Wait() {
+	p.rowMu.Lock()
+	for _, st := range p.Stats() {
+		if st.row != nil {
+			st.row.SetTotal(st.Total+1, true)
+			p.wg.Done()
+		}
+	}
+	p.rowMu.Unlock()
+
+	p.mpb.Wait()
+}
+
+// Stats returns the stats of the hosts, in the order of the first transfers.
+func (p *Progress) Stats() []HostStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]HostStat, 0, len(p.order))
+	for _, host := range p.order {
+		stats = append(stats, *p.hosts[host])
+	}
+
+	return stats
+}
+
+// Summary prints the table of the hosts, files, bytes, elapsed, speed and errors, then the failures.
+func (p *Progress) Summary(w io.Writer) {
+	stats := p.Stats()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Host", "Files", "Bytes", "Elapsed", "Speed", "Errors"})
+
+	for _, st := range stats {
+		elapsed := st.End.Sub(st.Start)
+		if st.Start.IsZero() || elapsed < 0 {
+			elapsed = 0
+		}
+
+		t.AppendRow(table.Row{
+			st.Host, fmt.Sprintf("%d/%d", st.Done, st.Files), ss.IBytes(uint64(st.Bytes)),
+			elapsed.Round(time.Millisecond), speed(st.Bytes, elapsed), len(st.Errors),
+		})
+	}
+
+	t.Render()
+
+	for _, st := range stats {
+		for _, e := range st.Errors {
+			fmt.Fprintf(w, "%s: %s\n", st.Host, e)
+		}
+	}
+}
+
+// HasErrors tells any host failed.
+func (p *Progress) HasErrors() bool {
+	for _, st := range p.Stats() {
+		if len(st.Errors) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func speed(bytes int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return "-"
+	}
+
+	return ss.IBytes(uint64(float64(bytes)/elapsed.Seconds())) + "/s"
+}
+
+// fileBar is the mpb bar of a file.
+type fileBar struct {
+	p     *Progress
+	st    *HostStat
+	bar   *mpb.Bar
+	size  int64
+	start time.Time
+}
+
+func (b *fileBar) Add(n int) {
+	b.bar.IncrBy(n, time.Since(b.start))
+	b.p.add(b.st, n)
+}
+
+func (b *fileBar) Finish(err error) {
+	if err != nil {
+		b.p.mpb.Abort(b.bar, false)
+	} else {
+		b.bar.SetTotal(b.size, true)
+	}
+
+	b.p.finish(b.st, err)
+	b.p.wg.Done()
+}
+
+// hostBar counts a file into the row of the host.
+type hostBar struct {
+	p  *Progress
+	st *HostStat
+}
+
+func (b *hostBar) Add(n int) {
+	b.st.row.IncrBy(n)
+	b.p.add(b.st, n)
+}
+
+func (b *hostBar) Finish(err error) { b.p.finish(b.st, err) }
+
+// hostDecorator decorates the row of the host by files done/total, bytes, speed, ETA and errors.
+type hostDecorator struct {
+	decor.WC
+	p  *Progress
+	st *HostStat
+}
+
+func (d *hostDecorator) Decor(*decor.Statistics) string {
+	d.p.mu.Lock()
+	st := *d.st
+	d.p.mu.Unlock()
+
+	elapsed := time.Since(st.Start)
+	if st.Start.IsZero() {
+		elapsed = 0
+	}
+
+	eta := "-"
+	if st.Bytes > 0 && st.Total > st.Bytes {
+		eta = (time.Duration(float64(elapsed) / float64(st.Bytes) * float64(st.Total-st.Bytes))).Round(time.Second).String()
+	}
+
+	return d.FormatMsg(fmt.Sprintf("files %d/%d  %s/%s  %s  ETA %s  errors %d",
+		st.Done, st.Files, ss.IBytes(uint64(st.Bytes)), ss.IBytes(uint64(st.Total)), speed(st.Bytes, elapsed), eta, len(st.Errors)))
+}
+
+// termBar is the cheggaaa/pb bar.
+type termBar struct {
+	bar *pb.ProgressBar
+}
+
+// NewTermBar creates the cheggaaa/pb bar of size bytes writing to w, refreshed every second.
+func NewTermBar(size int64, w io.Writer) Bar {
+	bar := pb.New64(size)
+	bar.SetRefreshRate(time.Second)
+	bar.SetWriter(w)
+	bar.Set(pb.Bytes, true)
+	bar.Start()
+
+	return &termBar{bar: bar}
+}
+
+func (b *termBar) Add(n int) { b.bar.Add(n) }
+
+func (b *termBar) Finish(error) { b.bar.Finish() }
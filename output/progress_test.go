@@ -0,0 +1,47 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	for _, aggregate := range []bool{true, false} {
+		p := NewProgress(aggregate, io.Discard)
+		p.Plan("web01", 2, 30)
+
+		bar := p.Bar("web01", "", "a.txt", 10)
+		bar.Add(10)
+		bar.Finish(nil)
+
+		bar = p.Bar("web01", "", "b.txt", 20)
+		bar.Add(5)
+		bar.Finish(errors.New("broken pipe"))
+		p.Fail("web01", "b.txt", errors.New("broken pipe"))
+
+		bar = p.Bar("web02", "", "a.txt", 10)
+		bar.Add(10)
+		bar.Finish(nil)
+
+		p.Wait()
+
+		stats := p.Stats()
+		assert.Equal(t, 2, len(stats))
+		assert.Equal(t, "web01", stats[0].Host)
+		assert.Equal(t, 1, stats[0].Done)
+		assert.Equal(t, 2, stats[0].Files)
+		assert.Equal(t, int64(15), stats[0].Bytes)
+		assert.Equal(t, int64(30), stats[0].Total)
+		assert.Equal(t, 1, stats[1].Files)
+		assert.True(t, p.HasErrors())
+
+		var b strings.Builder
+		p.Summary(&b)
+		assert.Contains(t, b.String(), "1/2")
+		assert.Contains(t, b.String(), "web01: b.txt: broken pipe\n")
+	}
+}
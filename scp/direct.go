@@ -0,0 +1,109 @@
+package scp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	sshl "github.com/bingoohuang/bssh/ssh"
+	"github.com/bingoohuang/ngg/ss"
+)
+
+// directPush streams the From.Path from the source host straight to the target hosts,
+// by the ssh command on the source host with the local ssh-agent forwarded, the data never goes through local.
+func (cp *Scp) directPush(fclient *Connect, tclients []*Connect, dst string) {
+	fow := fclient.Output.NewWriter()
+
+	if !hasCommands(fclient.SSH, append(cp.tarCommands(), "ssh")...) {
+		fmt.Fprintf(fow, "direct requires %s and ssh on %s\n", strings.Join(cp.tarCommands(), " and "), fclient.Server)
+		return
+	}
+
+	exit := make(chan bool)
+
+	for _, tc := range tclients {
+		tclient := tc
+
+		go func() {
+			defer func() { exit <- true }()
+
+			for _, p := range cp.From.Path {
+				event := audit.Start(audit.ActionScpCopy, fclient.Server, tclient.Server)
+				event.Source, event.Target = fclient.Server+":"+p, tclient.Server+":"+dst
+
+				err := cp.directPushPath(fclient, tclient.Server, p, dst)
+				event.End(err)
+
+				if err != nil {
+					fmt.Fprintf(fow, "direct %s->%s:%s error %v\n", p, tclient.Server, dst, err)
+					cp.Progress.Fail(tclient.Server, p, err)
+					continue
+				}
+
+				fmt.Fprintf(fow, "direct %s->%s:%s done\n", p, tclient.Server, dst)
+			}
+		}()
+	}
+
+	for range tclients {
+		<-exit
+	}
+}
+
+func (cp *Scp) directPushPath(fclient *Connect, target, p, dst string) error {
+	command, err := cp.directCommand(fclient.Server, target, p, dst)
+	if err != nil {
+		return err
+	}
+
+	session, err := fclient.SSH.NewSession()
+	if err != nil {
+		return err
+	}
+
+	defer session.Close()
+
+	if fclient.forwardAgent != nil {
+		fclient.forwardAgent(session)
+	}
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	session.Stdout = io.Discard
+
+	if err := session.Run(command); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// directCommand returns the command on the source host, which streams p to dst of the target by ssh.
+// The target is connected by its address (with the IP2 override), so the targets behind proxies
+// and the rate limits are not supported, and its host key must be known by the source host.
+func (cp *Scp) directCommand(source, target, p, dst string) (string, error) {
+	c, ok := cp.Config.Server[target]
+	if !ok || c.Addr == "" {
+		return "", fmt.Errorf("unknown address of %s", target)
+	}
+
+	if route, err := sshl.ProxyRoute(target, cp.Config); err != nil || route != "" {
+		return "", fmt.Errorf("direct does not support the proxy route of %s", target)
+	}
+
+	if limit := cp.Run.RateLimit(); limit.Limited(source) || limit.Limited(target) {
+		return "", fmt.Errorf("direct does not support the rate limit of %s or %s", source, target)
+	}
+
+	addr, port := sshl.ServerAddr(c)
+	if c.User != "" {
+		addr = c.User + "@" + addr
+	}
+
+	sshCmd := "ssh -o BatchMode=yes -p " + ss.Or(port, "22") + " " + common.ShellQuote(addr)
+
+	return cp.createCommand(p) + " | " + sshCmd + " " + common.ShellQuote(cp.extractCommand(dst)), nil
+}
@@ -0,0 +1,40 @@
+package scp
+
+import (
+	"testing"
+
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirect(t *testing.T) {
+	cp := &Scp{
+		Config: conf.Config{Server: map[string]conf.ServerConfig{
+			"web02":  {Addr: "10.0.0.2", Port: "2222", User: "app"},
+			"jump":   {Addr: "10.0.0.9"},
+			"behind": {Addr: "10.0.1.2", Proxy: "jump"},
+		}},
+		From: Info{Server: []string{"web01", "db01"}},
+		To:   Info{Path: []string{"/backup"}},
+	}
+
+	assert.Equal(t, "/backup/web01", cp.viaPushDest("web01"))
+
+	command, err := cp.directCommand("web01", "web02", "/data/logs", "/backup/web01")
+	assert.Nil(t, err)
+	assert.Equal(t, `tar -c -f - -C '/data' 'logs' | ssh -o BatchMode=yes -p 2222 'app@10.0.0.2' `+
+		`'mkdir -p '\''/backup/web01'\'' && tar -x --no-same-owner -f - -C '\''/backup/web01'\'''`, command)
+
+	_, err = cp.directCommand("web01", "unknown", "/data/logs", "/backup")
+	assert.NotNil(t, err)
+
+	_, err = cp.directCommand("web01", "behind", "/data/logs", "/backup")
+	assert.NotNil(t, err, "the target behind a proxy")
+
+	t.Setenv("RATELIMIT", "1M")
+	_, err = cp.directCommand("web01", "web02", "/data/logs", "/backup")
+	assert.NotNil(t, err, "the rate limit")
+
+	cp.From.Server = []string{"web01"}
+	assert.Equal(t, "/backup", cp.viaPushDest("web01"))
+}
@@ -8,18 +8,19 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/output"
 	sshl "github.com/bingoohuang/bssh/ssh"
 	"github.com/bingoohuang/ngg/ss"
 	"github.com/pkg/sftp"
-	"github.com/vbauerster/mpb"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -43,9 +44,24 @@ type Scp struct {
 	Parallel    bool
 	ParallelNum int
 
+	// resume and verify options
+	Option common.TransferOption
+
+	// include and exclude filter
+	Filter *common.Filter
+
+	// Tar streams the files as a tar archive over an exec session, Compress is gzip or zstd.
+	Tar      bool
+	Compress string
+
+	// Direct streams from the source hosts straight to the target hosts by ssh, in the remote to remote copy.
+	Direct bool
+
+	// Aggregate shows a row per host instead of a bar per file
+	Aggregate bool
+
 	// progress bar
-	Progress   *mpb.Progress
-	ProgressWG *sync.WaitGroup
+	Progress *output.Progress
 }
 
 // Info ...
@@ -68,6 +84,12 @@ type Connect struct {
 	// ssh connect
 	Connect *sftp.Client
 
+	// ssh client, to run the hash commands
+	SSH *ssh.Client
+
+	// forwardAgent forwards the local ssh-agent in the session
+	forwardAgent func(*ssh.Session)
+
 	// Output
 	Output *output.Output
 }
@@ -87,9 +109,12 @@ func (cp *Scp) Start(confpath string) {
 	cp.Run.Conf = cp.Config
 	cp.Run.CreateAuthMethodMap()
 
+	if cp.Direct {
+		cp.Run.SetupSSHAgent()
+	}
+
 	// Create Progress bar struct
-	cp.ProgressWG = new(sync.WaitGroup)
-	cp.Progress = mpb.New(mpb.WithWaitGroup(cp.ProgressWG))
+	cp.Progress = output.NewProgress(cp.Aggregate, os.Stdout)
 
 	switch {
 	// remote to remote
@@ -125,7 +150,7 @@ func (cp *Scp) push() {
 	pathset := make([]PathSet, len(cp.From.Path))
 
 	for i, p := range cp.From.Path {
-		data, err := common.WalkDir(p)
+		data, err := common.WalkDirFilter(p, cp.Filter)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "common.WalkDir error %v\n", err)
 			continue
@@ -146,8 +171,7 @@ func (cp *Scp) push() {
 		<-exit
 	}
 
-	// wait 0.3 sec
-	time.Sleep(300 * time.Millisecond)
+	cp.wait()
 
 	// exit messages
 	fmt.Println("all push exit.")
@@ -156,21 +180,47 @@ func (cp *Scp) push() {
 func pushByClient(exit chan bool, client *Connect, pathset []PathSet, cp *Scp) {
 	defer func() { exit <- true }()
 
-	client.Output.Create(client.Server)
 	ow := client.Output.NewWriter()
-	ftp := client.Connect
+
+	if cp.useTar(client, ow) {
+		if err := cp.pushTar(client, pathset); err != nil {
+			fmt.Fprintf(ow, "cp.pushTar error %v\n", err)
+			cp.Progress.Fail(client.Server, "tar "+cp.To.Path[0], err)
+		}
+
+		return
+	}
+
+	files, size := planPathset(pathset)
+	cp.Progress.Plan(client.Server, files, size)
 
 	// push path
 	for _, p := range pathset {
 		for _, path := range p.PathSlice {
-			if err := cp.pushPath(ftp, ow, client.Output, p.Base, path); err != nil {
+			if err := cp.pushPath(client, ow, p.Base, path); err != nil {
 				fmt.Fprintf(os.Stderr, "cp.pushPath error %v\n", err)
+				cp.Progress.Fail(client.Server, path, err)
 			}
 		}
 	}
 }
 
-func (cp *Scp) pushPath(ftp *sftp.Client, ow io.Writer, output *output.Output, base, path string) (err error) {
+// planPathset returns the count and the total size of the files to push in pathset.
+func planPathset(pathset []PathSet) (files int, size int64) {
+	for _, p := range pathset {
+		for _, path := range p.PathSlice {
+			if fi, err := os.Lstat(path); err == nil && !fi.IsDir() {
+				files, size = files+1, size+fi.Size()
+			}
+		}
+	}
+
+	return files, size
+}
+
+func (cp *Scp) pushPath(client *Connect, ow io.Writer, base, path string) (err error) {
+	ftp, output := client.Connect, client.Output
+
 	// get rel path
 	relpath, _ := filepath.Rel(base, path)
 	rpath := filepath.Join(cp.To.Path[0], relpath)
@@ -191,7 +241,13 @@ func (cp *Scp) pushPath(ftp *sftp.Client, ow io.Writer, output *output.Output, b
 
 		defer lf.Close()
 
-		if err := cp.pushFile(lf, ftp, output, rpath, fInfo); err != nil {
+		event := audit.Start(audit.ActionScpPut, output.Server)
+		event.Source, event.Target, event.Size = path, output.Server+":"+rpath, fInfo.Size()
+
+		err = cp.pushFile(lf, common.LocalHasher(cp.Option.HashAlgo(), path), client, rpath, fInfo)
+		event.End(err)
+
+		if err != nil {
 			fmt.Fprintf(ow, "cp.pushFile %s->%s error %v\n", path, rpath, err)
 			return err
 		}
@@ -200,8 +256,9 @@ func (cp *Scp) pushPath(ftp *sftp.Client, ow io.Writer, output *output.Output, b
 	return ftp.Chmod(rpath, fInfo.Mode())
 }
 
-// pushfile put file to path.
-func (cp *Scp) pushFile(lf io.Reader, ftp *sftp.Client, output *output.Output, path string, fInfo os.FileInfo) (err error) {
+// pushfile put file to path, continued from the existing file if resume, and verified if verify.
+func (cp *Scp) pushFile(lf io.ReadSeeker, srcHash common.HashFunc, client *Connect, path string, fInfo os.FileInfo) (err error) {
+	ftp, output := client.Connect, client.Output
 	ow := output.NewWriter()
 
 	dir := filepath.Dir(path)
@@ -211,7 +268,15 @@ func (cp *Scp) pushFile(lf io.Reader, ftp *sftp.Client, output *output.Output, p
 		return err
 	}
 
-	rf, err := ftp.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	size := fInfo.Size()
+	dstHash := common.RemoteHasher(client.SSH, ftp, cp.Option.HashAlgo(), path)
+
+	var offset int64
+	if stat, err := ftp.Stat(path); err == nil && cp.Option.Resume {
+		offset = cp.Option.ResumeOffset(size, stat.Size(), srcHash, dstHash)
+	}
+
+	rf, err := ftp.OpenFile(path, common.OpenFlag(offset))
 	if err != nil {
 		fmt.Fprintf(ow, "ftp.OpenFile error %v\n", err)
 
@@ -220,48 +285,88 @@ func (cp *Scp) pushFile(lf io.Reader, ftp *sftp.Client, output *output.Output, p
 
 	defer rf.Close()
 
-	rd := io.TeeReader(common.CreateRateLimit(lf), rf)
+	if err := common.SeekResume(offset, lf, rf, ow, path); err != nil {
+		return err
+	}
+
+	rd := io.TeeReader(cp.Run.RateLimit().Reader(client.Server, lf), rf)
 
 	// copy to data
-	cp.ProgressWG.Add(1)
+	err = output.ProgressPrinter(size-offset, rd, path+" ("+ss.IBytes(uint64(size))+", "+fInfo.ModTime().Format("2006-01-02 15:04:05")+")")
+	if err != nil {
+		return err
+	}
 
-	size := fInfo.Size()
-	return output.ProgressPrinter(size, rd, path+" ("+ss.IBytes(uint64(size))+", "+fInfo.ModTime().Format("2006-01-02 15:04:05")+")")
+	_ = rf.Close()
+
+	return cp.Option.VerifyHash(srcHash, dstHash)
 }
 
+// viaPush copies from the source hosts to the target hosts, a per source subdirectory if multiple sources.
 func (cp *Scp) viaPush() {
-	from := cp.From.Server[0] // string
-	to := cp.To.Server        // []string
-
-	fclient := cp.createScpConnects([]string{from})
-	tclient := cp.createScpConnects(to)
+	fclients := cp.createScpConnects(cp.From.Server)
+	tclients := cp.createScpConnects(cp.To.Server)
 
-	if len(fclient) == 0 || len(tclient) == 0 {
+	if len(fclients) == 0 || len(tclients) == 0 {
 		fmt.Fprintf(os.Stderr, "There is no host to connect to\n")
 		return
 	}
 
-	// pull and push data
-	for _, path := range cp.From.Path {
-		cp.viaPushPath(path, fclient[0], tclient)
+	exit := make(chan bool)
+
+	// pull and push data of the sources parallel
+	for _, c := range fclients {
+		fclient := c
+
+		go func() {
+			defer func() { exit <- true }()
+
+			cp.viaPushFrom(fclient, tclients)
+		}()
 	}
 
-	// wait 0.3 sec
-	time.Sleep(300 * time.Millisecond)
+	for range fclients {
+		<-exit
+	}
+
+	cp.wait()
 
 	// exit messages
 	fmt.Println("all push exit.")
 }
 
-func (cp *Scp) viaPushPath(path string, fclient *Connect, tclients []*Connect) {
+func (cp *Scp) viaPushFrom(fclient *Connect, tclients []*Connect) {
+	dst := cp.viaPushDest(fclient.Server)
+
+	switch {
+	case cp.Direct:
+		cp.directPush(fclient, tclients, dst)
+	case cp.Tar && cp.useTarAll(append([]*Connect{fclient}, tclients...)):
+		cp.viaPushTar(fclient, tclients, dst)
+	default:
+		for _, root := range cp.From.Path {
+			cp.viaPushPath(root, dst, fclient, tclients)
+		}
+	}
+}
+
+// viaPushDest returns the destination directory of the source host, the subdirectory by its name if multiple sources.
+func (cp *Scp) viaPushDest(server string) string {
+	if len(cp.From.Server) > 1 {
+		return path.Join(cp.To.Path[0], server)
+	}
+
+	return cp.To.Path[0]
+}
+
+func (cp *Scp) viaPushPath(root, dst string, fclient *Connect, tclients []*Connect) {
 	// from ftp client
 	ftp := fclient.Connect
 
 	// create from sftp walker
-	walker := ftp.Walk(path)
+	walker := ftp.Walk(root)
 
 	// get from sftp output writer
-	fclient.Output.Create(fclient.Server)
 	fow := fclient.Output.NewWriter()
 
 	for walker.Step() {
@@ -272,43 +377,57 @@ func (cp *Scp) viaPushPath(path string, fclient *Connect, tclients []*Connect) {
 		}
 
 		p := walker.Path()
-		if common.IsHidden(path, p) {
-			continue // ignore hidden files.
+		stat := walker.Stat()
+
+		if cp.Filter.Excluded(relPath(root, p), stat.IsDir()) {
+			if stat.IsDir() {
+				walker.SkipDir()
+			}
+
+			continue
 		}
 
-		stat := walker.Stat()
+		tp := path.Join(dst, relPath(path.Dir(root), p))
 
 		if stat.IsDir() { // is directory
 			for _, tc := range tclients {
-				_ = tc.Connect.Mkdir(p)
+				_ = tc.Connect.MkdirAll(tp)
 			}
 		} else { // is file
-			func() {
-				// open from server file
-				file, err := ftp.Open(p)
-				if err != nil {
-					fmt.Fprintf(fow, "ftp.Open Error: %v\n", err)
-					return
-				}
-				defer file.Close()
+			srcHash := common.RemoteHasher(fclient.SSH, ftp, cp.Option.HashAlgo(), p)
+			exit := make(chan bool)
 
-				exit := make(chan bool)
-				for _, tc := range tclients {
-					tclient := tc
-
-					go func() {
-						defer func() { exit <- true }()
-
-						tclient.Output.Create(tclient.Server)
-
-						_ = cp.pushFile(file, tclient.Connect, tclient.Output, p, stat)
-					}()
-				}
+			for _, tc := range tclients {
+				tclient := tc
+
+				go func() {
+					defer func() { exit <- true }()
+
+					// open from server file, for each target to seek on resume
+					file, err := ftp.Open(p)
+					if err != nil {
+						fmt.Fprintf(fow, "ftp.Open Error: %v\n", err)
+						cp.Progress.Fail(tclient.Server, p, err)
+						return
+					}
+					defer file.Close()
+
+					event := audit.Start(audit.ActionScpCopy, fclient.Server, tclient.Server)
+					event.Source, event.Target, event.Size = fclient.Server+":"+p, tclient.Server+":"+tp, stat.Size()
+
+					err = cp.pushFile(file, srcHash, tclient, tp, stat)
+					event.End(err)
+
+					if err != nil {
+						fmt.Fprintf(fow, "cp.pushFile %s->%s:%s error %v\n", p, tclient.Server, tp, err)
+						cp.Progress.Fail(tclient.Server, p, err)
+					}
+				}()
+			}
 
-				for range tclients {
-					<-exit
-				}
-			}()
+			for range tclients {
+				<-exit
+			}
 		}
 	}
 }
@@ -343,8 +462,7 @@ func (cp *Scp) pull() {
 		<-exit
 	}
 
-	// wait 0.3 sec
-	time.Sleep(300 * time.Millisecond)
+	cp.wait()
 
 	// exit messages
 	fmt.Println("all pull exit.")
@@ -355,7 +473,6 @@ func (cp *Scp) pullPath(client *Connect) {
 	ftp := client.Connect
 
 	// get output writer
-	client.Output.Create(client.Server)
 	ow := client.Output.NewWriter()
 
 	// basedir
@@ -369,7 +486,18 @@ func (cp *Scp) pullPath(client *Connect) {
 
 	baseDir, _ = filepath.Abs(baseDir)
 
-	// walk remote path
+	if cp.useTar(client, ow) {
+		cp.pullTar(client, baseDir, ow)
+		return
+	}
+
+	// walk remote path first, to plan the files of the progress.
+	var (
+		entries []pullEntry
+		files   int
+		size    int64
+	)
+
 	for _, path := range cp.From.Path {
 		globpath, err := tryEvalPath(ftp, path, ow)
 		if err != nil {
@@ -387,24 +515,43 @@ func (cp *Scp) pullPath(client *Connect) {
 				}
 
 				p := walker.Path()
-				if common.IsHidden(remoteBase, p) {
-					continue // ignore hidden files.
+				stat := walker.Stat()
+
+				if cp.Filter.Excluded(relPath(gp, p), stat.IsDir()) {
+					if stat.IsDir() {
+						walker.SkipDir()
+					}
+
+					continue
 				}
 
 				rp, _ := filepath.Rel(remoteBase, p)
-				lpath := filepath.Join(baseDir, rp)
+				entries = append(entries, pullEntry{path: p, lpath: filepath.Join(baseDir, rp), stat: stat})
 
-				stat := walker.Stat()
-				if stat.IsDir() { // create dir
-					_ = os.MkdirAll(lpath, 0o755)
-				} else { // create file
-					cp.createFile(stat, p, ow, lpath, client)
+				if !stat.IsDir() {
+					files, size = files+1, size+stat.Size()
 				}
-
-				_ = os.Chmod(lpath, stat.Mode())
 			}
 		}
 	}
+
+	cp.Progress.Plan(client.Server, files, size)
+
+	for _, e := range entries {
+		if e.stat.IsDir() { // create dir
+			_ = os.MkdirAll(e.lpath, 0o755)
+		} else { // create file
+			cp.createFile(e.stat, e.path, ow, e.lpath, client)
+		}
+
+		_ = os.Chmod(e.lpath, e.stat.Mode())
+	}
+}
+
+// pullEntry is the remote file or directory to pull, and its local path.
+type pullEntry struct {
+	path, lpath string
+	stat        os.FileInfo
 }
 
 func tryEvalPath(ftp *sftp.Client, path string, ow *io.PipeWriter) ([]string, error) {
@@ -429,6 +576,18 @@ func (cp *Scp) createFile(stat os.FileInfo, p string, ow io.Writer, lpath string
 	size := stat.Size()
 	ftp := client.Connect
 
+	event := audit.Start(audit.ActionScpGet, client.Server)
+	event.Source, event.Target, event.Size = client.Server+":"+p, lpath, size
+
+	var err error
+	defer func() {
+		event.End(err)
+
+		if err != nil {
+			cp.Progress.Fail(client.Server, p, err)
+		}
+	}()
+
 	// open remote file
 	rf, err := ftp.Open(p)
 	if err != nil {
@@ -438,8 +597,16 @@ func (cp *Scp) createFile(stat os.FileInfo, p string, ow io.Writer, lpath string
 
 	defer rf.Close()
 
+	srcHash := common.RemoteHasher(client.SSH, ftp, cp.Option.HashAlgo(), p)
+	dstHash := common.LocalHasher(cp.Option.HashAlgo(), lpath)
+
+	var offset int64
+	if lstat, e := os.Stat(lpath); e == nil && cp.Option.Resume {
+		offset = cp.Option.ResumeOffset(size, lstat.Size(), srcHash, dstHash)
+	}
+
 	// open local file
-	lf, err := os.OpenFile(lpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	lf, err := os.OpenFile(lpath, common.OpenFlag(offset), 0o644)
 	if err != nil {
 		fmt.Fprintf(ow, "os.OpenFile Error: %v\n", err)
 		return
@@ -447,11 +614,32 @@ func (cp *Scp) createFile(stat os.FileInfo, p string, ow io.Writer, lpath string
 
 	defer lf.Close()
 
-	rd := io.TeeReader(common.CreateRateLimit(rf), lf)
+	if err = common.SeekResume(offset, rf, lf, ow, p); err != nil {
+		fmt.Fprintf(ow, "Error: %v\n", err)
+		return
+	}
+
+	rd := io.TeeReader(cp.Run.RateLimit().Reader(client.Server, rf), lf)
 
-	cp.ProgressWG.Add(1)
-	if err := client.Output.ProgressPrinter(size, rd, p); err != nil {
+	if err = client.Output.ProgressPrinter(size-offset, rd, p); err != nil {
 		fmt.Fprintf(ow, "Error: %v\n", err)
+		return
+	}
+
+	if err = cp.Option.VerifyHash(srcHash, dstHash); err != nil {
+		fmt.Fprintf(ow, "Error: %s %v\n", p, err)
+	}
+}
+
+// wait waits the progress, and prints the summary if aggregate, multiple hosts or failed.
+func (cp *Scp) wait() {
+	cp.Progress.Wait()
+
+	// wait 0.3 sec
+	time.Sleep(300 * time.Millisecond)
+
+	if cp.Progress.Aggregate || len(cp.Progress.Stats()) > 1 || cp.Progress.HasErrors() {
+		cp.Progress.Summary(os.Stdout)
 	}
 }
 
@@ -487,11 +675,13 @@ func (cp *Scp) createScpConnects(targets []string) (result []*Connect) {
 				Conf:       cp.Config.Server[server],
 				AutoColor:  true,
 				Progress:   cp.Progress,
-				ProgressWG: cp.ProgressWG,
 			}
 
+			// the prompt is created once here, the connects are shared by the goroutines of the transfers.
+			o.Create(server)
+
 			// create ScpConnect
-			scpCon := &Connect{Server: server, Connect: ftp, Output: o}
+			scpCon := &Connect{Server: server, Connect: ftp, SSH: conn.Client, Output: o, forwardAgent: conn.ForwardSshAgent}
 
 			// append result
 			m.Lock()
@@ -0,0 +1,499 @@
+package scp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/output"
+	sshl "github.com/bingoohuang/bssh/ssh"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/pkg/sftp"
+)
+
+// SyncOption is the options of bssh sync.
+type SyncOption struct {
+	// Delete deletes the destination files not in the source.
+	Delete bool
+
+	// DryRun prints the changes only.
+	DryRun bool
+
+	// Checksum compares the files of the same size and mtime by sha256.
+	Checksum bool
+
+	// Filter is the include and exclude patterns.
+	Filter common.Filter
+}
+
+// syncEntry is a file, directory or symlink of the sync tree.
+type syncEntry struct {
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+	Link    string
+}
+
+// syncFS is the file system of one end of the sync, local or remote over sftp.
+type syncFS interface {
+	// Walk returns the entries under root by the relative slash paths, excluded ones are skipped.
+	Walk(root string, filter *common.Filter) (map[string]*syncEntry, error)
+	Join(root, rel string) string
+	Open(path string) (io.ReadCloser, error)
+	Create(path string) (io.WriteCloser, error)
+	MkdirAll(path string) error
+	Symlink(target, path string) error
+	Remove(path string, dir bool) error
+	Chmod(path string, mode os.FileMode) error
+	Chtimes(path string, mtime time.Time) error
+	Hasher(path string) common.HashFunc
+}
+
+// StartSync syncs From.Path[0] to To.Path[0], only the changed files are transferred.
+func (cp *Scp) StartSync(confpath string, option SyncOption) {
+	slist := append(cp.To.Server, cp.From.Server...)
+
+	cp.Run = sshl.NewRun(confpath)
+	cp.Run.ServerList = slist
+	cp.Run.Conf = cp.Config
+	cp.Run.CreateAuthMethodMap()
+
+	cp.Progress = output.NewProgress(cp.Aggregate, os.Stdout)
+
+	targets := cp.To.Server
+	if cp.From.IsRemote {
+		targets = cp.From.Server
+	}
+
+	clients := cp.createScpConnects(targets)
+	if len(clients) == 0 {
+		fmt.Fprintf(os.Stderr, "There is no host to connect to\n")
+		return
+	}
+
+	exit := make(chan bool)
+
+	for _, c := range clients {
+		client := c
+
+		go func() {
+			defer func() { exit <- true }()
+
+			ow := client.Output.NewWriter()
+			defer ow.Close()
+
+			remote := &remoteFS{ftp: client.Connect, hasher: func(p string) common.HashFunc {
+				return common.RemoteHasher(client.SSH, client.Connect, "sha256", p)
+			}}
+
+			s := &syncer{cp: cp, client: client, option: option, ow: ow}
+
+			if cp.From.IsRemote {
+				dst := ss.ExpandHome(cp.To.Path[0])
+				if len(clients) > 1 {
+					dst = filepath.Join(dst, client.Server)
+				}
+
+				s.sync(remote, cp.From.Path[0], localFS{}, dst)
+			} else {
+				s.sync(localFS{}, cp.From.Path[0], remote, cp.To.Path[0])
+			}
+		}()
+	}
+
+	for range clients {
+		<-exit
+	}
+
+	cp.wait()
+
+	fmt.Println("all sync exit.")
+}
+
+// syncer syncs the tree of one host.
+type syncer struct {
+	cp     *Scp
+	client *Connect
+	option SyncOption
+	ow     io.Writer
+
+	sent, deleted int
+
+	// removed is the destination directories removed for the type changes.
+	removed []string
+}
+
+func (s *syncer) sync(src syncFS, srcRoot string, dst syncFS, dstRoot string) {
+	srcEntries, err := src.Walk(srcRoot, &s.option.Filter)
+	if err != nil {
+		fmt.Fprintf(s.ow, "walk %s error %v\n", srcRoot, err)
+		s.cp.Progress.Fail(s.client.Server, srcRoot, err)
+		return
+	}
+
+	dstEntries, err := dst.Walk(dstRoot, &s.option.Filter)
+	if err != nil {
+		dstEntries = map[string]*syncEntry{}
+	}
+
+	if !s.option.DryRun {
+		if err := dst.MkdirAll(dstRoot); err != nil {
+			fmt.Fprintf(s.ow, "mkdir %s error %v\n", dstRoot, err)
+			s.cp.Progress.Fail(s.client.Server, dstRoot, err)
+			return
+		}
+	}
+
+	rels := make([]string, 0, len(srcEntries))
+	for rel := range srcEntries {
+		rels = append(rels, rel)
+	}
+
+	sort.Strings(rels)
+	s.plan(srcEntries, dstEntries)
+
+	for _, rel := range rels {
+		if err := s.syncOne(src, srcRoot, dst, dstRoot, rel, srcEntries[rel], dstEntries[rel]); err != nil {
+			fmt.Fprintf(s.ow, "sync %s error %v\n", rel, err)
+			s.cp.Progress.Fail(s.client.Server, rel, err)
+		}
+	}
+
+	if s.option.Delete {
+		s.deleteExtra(dst, dstRoot, srcEntries, dstEntries)
+	}
+
+	// mtimes of directories are changed by the files in them, set them at last.
+	if !s.option.DryRun {
+		for i := len(rels) - 1; i >= 0; i-- {
+			if e := srcEntries[rels[i]]; e.Mode.IsDir() {
+				_ = dst.Chtimes(dst.Join(dstRoot, rels[i]), e.ModTime)
+			}
+		}
+	}
+
+	fmt.Fprintf(s.ow, "sync done, %d sent, %d deleted\n", s.sent, s.deleted)
+}
+
+// plan adds the files changed by the size or mtime to the progress, the files changed by the checksum only
+// are counted when sent.
+func (s *syncer) plan(srcEntries, dstEntries map[string]*syncEntry) {
+	if s.option.DryRun {
+		return
+	}
+
+	files, size := 0, int64(0)
+
+	for rel, se := range srcEntries {
+		de := dstEntries[rel]
+		if se.Mode.IsRegular() && (de == nil || !de.Mode.IsRegular() || de.Size != se.Size || de.ModTime.Unix() != se.ModTime.Unix()) {
+			files, size = files+1, size+se.Size
+		}
+	}
+
+	if files > 0 {
+		s.cp.Progress.Plan(s.client.Server, files, size)
+	}
+}
+
+func (s *syncer) syncOne(src syncFS, srcRoot string, dst syncFS, dstRoot, rel string, se, de *syncEntry) error {
+	sp, dp := src.Join(srcRoot, rel), dst.Join(dstRoot, rel)
+
+	// the type is changed, remove the destination first.
+	if de != nil && de.Mode.Type() != se.Mode.Type() {
+		if err := s.apply("delete "+rel, func() error { return dst.Remove(dp, de.Mode.IsDir()) }); err != nil {
+			return err
+		}
+
+		if de.Mode.IsDir() {
+			s.removed = append(s.removed, rel+"/")
+		}
+
+		de = nil
+	}
+
+	switch {
+	case se.Mode.IsDir():
+		if de == nil {
+			return s.apply("mkdir "+rel+"/", func() error {
+				if err := dst.MkdirAll(dp); err != nil {
+					return err
+				}
+
+				return dst.Chmod(dp, se.Mode.Perm())
+			})
+		}
+	case se.Mode&os.ModeSymlink != 0:
+		if de == nil || de.Link != se.Link {
+			return s.apply("link "+rel+" -> "+se.Link, func() error {
+				if de != nil {
+					_ = dst.Remove(dp, false)
+				}
+
+				return dst.Symlink(se.Link, dp)
+			})
+		}
+
+		return nil
+	case !se.Mode.IsRegular():
+		return nil // sockets, devices and pipes are skipped.
+	case s.changed(src.Hasher(sp), dst.Hasher(dp), se, de):
+		s.sent++
+
+		return s.apply("send "+rel, func() error { return s.send(src, sp, dst, dp, se) })
+	}
+
+	if de.Mode.Perm() != se.Mode.Perm() {
+		return s.apply(fmt.Sprintf("chmod %s %o", rel, se.Mode.Perm()), func() error {
+			return dst.Chmod(dp, se.Mode.Perm())
+		})
+	}
+
+	return nil
+}
+
+// changed tells the file is changed, by the size and mtime, and the sha256 if checksum.
+func (s *syncer) changed(srcHash, dstHash common.HashFunc, se, de *syncEntry) bool {
+	if de == nil || de.Size != se.Size || de.ModTime.Unix() != se.ModTime.Unix() {
+		return true
+	}
+
+	if !s.option.Checksum {
+		return false
+	}
+
+	srcSum, err := srcHash(-1)
+	if err != nil {
+		return true
+	}
+
+	dstSum, err := dstHash(-1)
+
+	return err != nil || srcSum != dstSum
+}
+
+// send copies the file, and keeps its mode and mtime.
+func (s *syncer) send(src syncFS, sp string, dst syncFS, dp string, se *syncEntry) (err error) {
+	server := s.client.Server
+	action, source, target := audit.ActionScpPut, sp, server+":"+dp
+
+	if s.cp.From.IsRemote {
+		action, source, target = audit.ActionScpGet, server+":"+sp, dp
+	}
+
+	event := audit.Start(action, server)
+	event.Source, event.Target, event.Size = source, target, se.Size
+
+	defer func() { event.End(err) }()
+
+	rf, err := src.Open(sp)
+	if err != nil {
+		return err
+	}
+
+	defer rf.Close()
+
+	wf, err := dst.Create(dp)
+	if err != nil {
+		return err
+	}
+
+	defer wf.Close()
+
+	if err := s.client.Output.ProgressPrinter(se.Size, io.TeeReader(s.cp.Run.RateLimit().Reader(s.client.Server, rf), wf), sp); err != nil {
+		return err
+	}
+
+	if err := wf.Close(); err != nil {
+		return err
+	}
+
+	if err := dst.Chmod(dp, se.Mode.Perm()); err != nil {
+		return err
+	}
+
+	return dst.Chtimes(dp, se.ModTime)
+}
+
+// deleteExtra deletes the destination entries not in the source, the deepest first.
+func (s *syncer) deleteExtra(dst syncFS, dstRoot string, srcEntries, dstEntries map[string]*syncEntry) {
+	var extra []string
+
+	for rel := range dstEntries {
+		if _, ok := srcEntries[rel]; !ok && !s.isRemoved(rel) {
+			extra = append(extra, rel)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(extra)))
+
+	for _, rel := range extra {
+		de := dstEntries[rel]
+		dp := dst.Join(dstRoot, rel)
+
+		if err := s.apply("delete "+rel, func() error { return dst.Remove(dp, de.Mode.IsDir()) }); err != nil {
+			fmt.Fprintf(s.ow, "delete %s error %v\n", rel, err)
+			s.cp.Progress.Fail(s.client.Server, rel, err)
+			continue
+		}
+
+		s.deleted++
+	}
+}
+
+func (s *syncer) isRemoved(rel string) bool {
+	for _, dir := range s.removed {
+		if strings.HasPrefix(rel, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apply prints the change, and does it unless dry run.
+func (s *syncer) apply(change string, fn func() error) error {
+	if s.option.DryRun {
+		fmt.Fprintf(s.ow, "%s (dry run)\n", change)
+		return nil
+	}
+
+	fmt.Fprintf(s.ow, "%s\n", change)
+
+	return fn()
+}
+
+// localFS is the local file system.
+type localFS struct{}
+
+func (localFS) Walk(root string, filter *common.Filter) (map[string]*syncEntry, error) {
+	entries := map[string]*syncEntry{}
+
+	err := filter.Walk(root, func(p, rel string, info os.FileInfo) error {
+		if rel == "." {
+			return nil
+		}
+
+		e := &syncEntry{Mode: info.Mode(), Size: info.Size(), ModTime: info.ModTime()}
+		if info.Mode()&os.ModeSymlink != 0 {
+			e.Link, _ = os.Readlink(p)
+		}
+
+		entries[rel] = e
+
+		return nil
+	})
+
+	return entries, err
+}
+
+func (localFS) Join(root, rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+
+func (localFS) Open(p string) (io.ReadCloser, error) { return os.Open(p) }
+
+func (localFS) Create(p string) (io.WriteCloser, error) {
+	return os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+}
+
+func (localFS) MkdirAll(p string) error { return os.MkdirAll(p, 0o755) }
+
+func (localFS) Symlink(target, p string) error { return os.Symlink(target, p) }
+
+func (localFS) Remove(p string, dir bool) error {
+	if dir {
+		return os.RemoveAll(p)
+	}
+
+	return os.Remove(p)
+}
+
+func (localFS) Chmod(p string, mode os.FileMode) error { return os.Chmod(p, mode) }
+
+func (localFS) Chtimes(p string, mtime time.Time) error { return os.Chtimes(p, mtime, mtime) }
+
+func (localFS) Hasher(p string) common.HashFunc { return common.LocalHasher("sha256", p) }
+
+// remoteFS is the remote file system over sftp.
+type remoteFS struct {
+	ftp    *sftp.Client
+	hasher func(p string) common.HashFunc
+}
+
+func (r *remoteFS) Walk(root string, filter *common.Filter) (map[string]*syncEntry, error) {
+	if _, err := r.ftp.Lstat(root); err != nil {
+		return nil, err
+	}
+
+	entries := map[string]*syncEntry{}
+
+	for walker := r.ftp.Walk(root); walker.Step(); {
+		if err := walker.Err(); err != nil {
+			return entries, err
+		}
+
+		rel := relPath(root, walker.Path())
+		if rel == "" {
+			continue
+		}
+
+		info := walker.Stat()
+		if filter.Excluded(rel, info.IsDir()) {
+			if info.IsDir() {
+				walker.SkipDir()
+			}
+
+			continue
+		}
+
+		e := &syncEntry{Mode: info.Mode(), Size: info.Size(), ModTime: info.ModTime()}
+		if info.Mode()&os.ModeSymlink != 0 {
+			e.Link, _ = r.ftp.ReadLink(walker.Path())
+		}
+
+		entries[rel] = e
+	}
+
+	return entries, nil
+}
+
+func (r *remoteFS) Join(root, rel string) string { return path.Join(root, rel) }
+
+func (r *remoteFS) Open(p string) (io.ReadCloser, error) { return r.ftp.Open(p) }
+
+func (r *remoteFS) Create(p string) (io.WriteCloser, error) {
+	return r.ftp.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+func (r *remoteFS) MkdirAll(p string) error { return r.ftp.MkdirAll(p) }
+
+func (r *remoteFS) Symlink(target, p string) error { return r.ftp.Symlink(target, p) }
+
+func (r *remoteFS) Remove(p string, dir bool) error {
+	if !dir {
+		return r.ftp.Remove(p)
+	}
+
+	return r.ftp.RemoveAll(p)
+}
+
+func (r *remoteFS) Chmod(p string, mode os.FileMode) error { return r.ftp.Chmod(p, mode) }
+
+func (r *remoteFS) Chtimes(p string, mtime time.Time) error { return r.ftp.Chtimes(p, mtime, mtime) }
+
+func (r *remoteFS) Hasher(p string) common.HashFunc { return r.hasher(p) }
+
+// relPath returns the relative slash path of p under root.
+func relPath(root, p string) string {
+	if root = path.Clean(root); root == "." {
+		return path.Clean(p)
+	}
+
+	return strings.TrimPrefix(strings.TrimPrefix(path.Clean(p), root), "/")
+}
@@ -0,0 +1,68 @@
+package scp
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSync(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	write := func(p, content string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.Nil(t, os.WriteFile(p, []byte(content), 0o644))
+		assert.Nil(t, os.Chtimes(p, mtime, mtime))
+	}
+
+	write(filepath.Join(src, "a.txt"), "a")
+	write(filepath.Join(src, "sub/b.txt"), "b")
+	write(filepath.Join(src, "sub/debug.log"), "log")
+	assert.Nil(t, os.Symlink("a.txt", filepath.Join(src, "link")))
+	assert.Nil(t, os.Chmod(filepath.Join(src, "a.txt"), 0o600))
+
+	write(filepath.Join(dst, "a.txt"), "xy")      // changed
+	write(filepath.Join(dst, "sub/b.txt"), "b")   // unchanged
+	write(filepath.Join(dst, "extra.txt"), "old") // deleted
+	write(filepath.Join(dst, "keep.log"), "log")  // excluded, not deleted
+
+	cp := &Scp{Progress: output.NewProgress(false, io.Discard)}
+	client := &Connect{Server: "web01", Output: &output.Output{Server: "web01", Progress: cp.Progress}}
+	s := &syncer{cp: cp, client: client, ow: io.Discard, option: SyncOption{
+		Delete: true, Filter: common.Filter{Exclude: []string{"*.log"}, Hidden: true},
+	}}
+
+	s.sync(localFS{}, src, localFS{}, dst)
+	cp.Progress.Wait()
+
+	assert.Equal(t, 1, s.sent)
+	assert.Equal(t, 1, s.deleted)
+
+	// only a.txt is planned, and sent.
+	stats := cp.Progress.Stats()
+	assert.Equal(t, 1, len(stats))
+	assert.Equal(t, 1, stats[0].Files)
+	assert.Equal(t, 1, stats[0].Done)
+	assert.Equal(t, int64(1), stats[0].Total)
+
+	data, _ := os.ReadFile(filepath.Join(dst, "a.txt"))
+	assert.Equal(t, "a", string(data))
+
+	stat, _ := os.Stat(filepath.Join(dst, "a.txt"))
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+	assert.Equal(t, mtime.Unix(), stat.ModTime().Unix())
+
+	link, _ := os.Readlink(filepath.Join(dst, "link"))
+	assert.Equal(t, "a.txt", link)
+
+	assert.NoFileExists(t, filepath.Join(dst, "extra.txt"))
+	assert.NoFileExists(t, filepath.Join(dst, "sub/debug.log"))
+	assert.FileExists(t, filepath.Join(dst, "keep.log"))
+}
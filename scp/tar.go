@@ -0,0 +1,632 @@
+package scp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/ngg/ss"
+	"golang.org/x/crypto/ssh"
+)
+
+// Compression modes of the tar transfer.
+const (
+	compressGzip = "gzip"
+	compressZstd = "zstd"
+)
+
+// ParseCompress checks the compression of --compress.
+func ParseCompress(s string) (string, error) {
+	switch s = strings.ToLower(s); s {
+	case "", compressGzip:
+		return s, nil
+	case compressZstd:
+		if _, err := exec.LookPath("zstd"); err != nil {
+			return "", fmt.Errorf("compress zstd requires the zstd command: %w", err)
+		}
+
+		return s, nil
+	}
+
+	return "", fmt.Errorf("unknown compress %q, zstd or gzip", s)
+}
+
+// tarCommands returns the commands required on the remote host for the tar transfer.
+func (cp *Scp) tarCommands() []string {
+	if cp.Compress == "" {
+		return []string{"tar"}
+	}
+
+	return []string{"tar", cp.Compress}
+}
+
+// hasCommands tells all the commands are available on the remote host.
+func hasCommands(client *ssh.Client, names ...string) bool {
+	session, err := client.NewSession()
+	if err != nil {
+		return false
+	}
+
+	defer session.Close()
+
+	checks := make([]string, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, "command -v "+name+" >/dev/null")
+	}
+
+	return session.Run(strings.Join(checks, " && ")) == nil
+}
+
+// useTar tells the tar transfer is used for the host, or falls back to sftp if the commands are missing.
+func (cp *Scp) useTar(client *Connect, ow io.Writer) bool {
+	if !cp.Tar {
+		return false
+	}
+
+	if !hasCommands(client.SSH, cp.tarCommands()...) {
+		fmt.Fprintf(ow, "%s not found, fall back to sftp\n", strings.Join(cp.tarCommands(), " or "))
+		return false
+	}
+
+	return true
+}
+
+// useTarAll tells the tar transfer is used for all the hosts of the remote to remote copy.
+func (cp *Scp) useTarAll(clients []*Connect) bool {
+	for _, c := range clients {
+		if !cp.useTar(c, c.Output.NewWriter()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// extractCommand returns the remote command to extract the tar stream of stdin into dir,
+// the files are owned by the login user like sftp, not by the uid recorded in the archive.
+func (cp *Scp) extractCommand(dir string) string {
+	cmd := "mkdir -p " + common.ShellQuote(dir) + " && "
+	if cp.Compress != "" {
+		cmd += cp.Compress + " -q -dc | "
+	}
+
+	return cmd + "tar -x --no-same-owner -f - -C " + common.ShellQuote(dir)
+}
+
+// createCommand returns the remote command to write the tar stream of p to stdout.
+func (cp *Scp) createCommand(p string) string {
+	cmd := "tar -c -f - -C " + common.ShellQuote(path.Dir(p)) + " " + common.ShellQuote(path.Base(p))
+	if cp.Compress != "" {
+		cmd += " | " + cp.Compress + " -q -c"
+	}
+
+	return cmd
+}
+
+// compressWriter returns the writer compressing into w, the zstd is compressed by the local zstd command.
+func (cp *Scp) compressWriter(w io.Writer) (io.WriteCloser, error) {
+	switch cp.Compress {
+	case compressGzip:
+		return gzip.NewWriter(w), nil
+	case compressZstd:
+		cmd := exec.Command("zstd", "-q", "-c")
+		cmd.Stdout = w
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+
+		return &cmdWriteCloser{WriteCloser: stdin, cmd: cmd}, nil
+	}
+
+	return nopWriteCloser{Writer: w}, nil
+}
+
+// decompressReader returns the reader decompressing r.
+func (cp *Scp) decompressReader(r io.Reader) (io.ReadCloser, error) {
+	switch cp.Compress {
+	case compressGzip:
+		return gzip.NewReader(r)
+	case compressZstd:
+		cmd := exec.Command("zstd", "-q", "-dc")
+		cmd.Stdin = r
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+
+		return &cmdReadCloser{ReadCloser: stdout, cmd: cmd}, nil
+	}
+
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// cmdWriteCloser closes the stdin of the command and waits it.
+type cmdWriteCloser struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdWriteCloser) Close() error {
+	_ = c.WriteCloser.Close()
+	return c.cmd.Wait()
+}
+
+// cmdReadCloser waits the command on close.
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	_, _ = io.Copy(io.Discard, c.ReadCloser)
+	return c.cmd.Wait()
+}
+
+// pushTar pushes the pathset to the host by a tar stream over an exec session.
+func (cp *Scp) pushTar(client *Connect, pathset []PathSet) (err error) {
+	var size int64
+
+	for _, p := range pathset {
+		for _, f := range p.PathSlice {
+			if info, e := os.Lstat(f); e == nil && info.Mode().IsRegular() {
+				size += info.Size()
+			}
+		}
+	}
+
+	target := cp.To.Path[0]
+	event := audit.Start(audit.ActionScpPut, client.Server)
+	event.Source, event.Target, event.Size = strings.Join(cp.From.Path, ","), client.Server+":"+target, size
+
+	defer func() { event.End(err) }()
+
+	session, err := client.SSH.NewSession()
+	if err != nil {
+		return err
+	}
+
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := session.Start(cp.extractCommand(target)); err != nil {
+		return err
+	}
+
+	cw, err := cp.compressWriter(stdin)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	go func() { _ = pw.CloseWithError(writeTar(pw, pathset)) }()
+
+	label := fmt.Sprintf("tar %s (%s)", target, ss.IBytes(uint64(size)))
+	err = client.Output.ProgressPrinter(size, io.TeeReader(cp.Run.RateLimit().Reader(client.Server, pr), cw), label)
+
+	if e := cw.Close(); err == nil {
+		err = e
+	}
+
+	_ = stdin.Close()
+
+	if e := session.Wait(); err == nil && e != nil {
+		err = fmt.Errorf("%w: %s", e, strings.TrimSpace(stderr.String()))
+	}
+
+	return err
+}
+
+// writeTar writes the files of pathset into the tar stream, named by the relative paths from their bases.
+func writeTar(w io.Writer, pathset []PathSet) error {
+	tw := tar.NewWriter(w)
+
+	for _, p := range pathset {
+		for _, f := range p.PathSlice {
+			if err := writeTarFile(tw, p.Base, strings.TrimSuffix(f, "/")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, base, f string) error {
+	info, err := os.Lstat(f)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(f); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	rel, _ := filepath.Rel(base, f)
+	hdr.Name = filepath.ToSlash(rel)
+
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	lf, err := os.Open(f)
+	if err != nil {
+		return err
+	}
+
+	defer lf.Close()
+
+	_, err = io.Copy(tw, lf)
+
+	return err
+}
+
+// pullTar pulls the From.Path from the host into baseDir by the tar streams over exec sessions.
+func (cp *Scp) pullTar(client *Connect, baseDir string, ow *io.PipeWriter) {
+	var paths []string
+
+	for _, p := range cp.From.Path {
+		if globpath, err := tryEvalPath(client.Connect, p, ow); err == nil {
+			paths = append(paths, globpath...)
+		}
+	}
+
+	for _, p := range paths {
+		event := audit.Start(audit.ActionScpGet, client.Server)
+		event.Source, event.Target = client.Server+":"+p, baseDir
+
+		files, size, err := cp.pullTarPath(client, p, baseDir)
+		event.Size = size
+		event.End(err)
+
+		if err != nil {
+			fmt.Fprintf(ow, "tar %s error %v\n", p, err)
+			cp.Progress.Fail(client.Server, p, err)
+			continue
+		}
+
+		fmt.Fprintf(ow, "tar %s done, %d files, %s\n", p, files, ss.IBytes(uint64(size)))
+	}
+}
+
+func (cp *Scp) pullTarPath(client *Connect, p, baseDir string) (files int, size int64, err error) {
+	session, err := client.SSH.NewSession()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := session.Start(cp.createCommand(p)); err != nil {
+		return 0, 0, err
+	}
+
+	r, err := cp.decompressReader(cp.Run.RateLimit().Reader(client.Server, stdout))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	files, size, err = extractTar(r, baseDir, cp.Filter)
+
+	if e := r.Close(); err == nil {
+		err = e
+	}
+
+	if e := session.Wait(); err == nil && e != nil {
+		err = fmt.Errorf("%w: %s", e, strings.TrimSpace(stderr.String()))
+	}
+
+	return files, size, err
+}
+
+// tarSkipper skips the tar entries excluded by the filter, and the entries in the excluded directories.
+type tarSkipper struct {
+	filter  *common.Filter
+	skipped []string
+}
+
+// skip tells the entry is skipped, the first segment of name is the transfer root.
+func (s *tarSkipper) skip(name string, isDir bool) bool {
+	for _, dir := range s.skipped {
+		if strings.HasPrefix(name, dir) {
+			return true
+		}
+	}
+
+	_, rel, _ := strings.Cut(name, "/")
+	if !s.filter.Excluded(rel, isDir) {
+		return false
+	}
+
+	if isDir {
+		s.skipped = append(s.skipped, name+"/")
+	}
+
+	return true
+}
+
+// extractTar extracts the tar stream into dir, the modes, mtimes and symlinks are kept.
+func extractTar(r io.Reader, dir string, filter *common.Filter) (files int, size int64, err error) {
+	tr := tar.NewReader(r)
+	skipper := &tarSkipper{filter: filter}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, size, nil
+		} else if err != nil {
+			return files, size, err
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return files, size, fmt.Errorf("unsafe path %s in tar", hdr.Name)
+		}
+
+		if skipper.skip(name, hdr.Typeflag == tar.TypeDir) {
+			continue
+		}
+
+		if err := checkSymlinks(dir, name, hdr.Typeflag == tar.TypeDir); err != nil {
+			return files, size, err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		mode := os.FileMode(hdr.Mode).Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return files, size, err
+			}
+
+			_ = os.Chmod(target, mode)
+		case tar.TypeReg:
+			// replace the symlink extracted before, instead of writing through it.
+			if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				_ = os.Remove(target)
+			}
+
+			if err := extractFile(tr, target, mode); err != nil {
+				return files, size, err
+			}
+
+			_ = os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+			files, size = files+1, size+hdr.Size
+		case tar.TypeSymlink:
+			_ = os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return files, size, err
+			}
+		}
+	}
+}
+
+// checkSymlinks rejects the entry name whose parent directory in dir is a symlink,
+// or the directory entry itself is a symlink, so that nothing is written outside dir through the symlinks.
+func checkSymlinks(dir, name string, isDir bool) error {
+	parts := strings.Split(name, "/")
+	if !isDir {
+		parts = parts[:len(parts)-1]
+	}
+
+	p := dir
+	for _, part := range parts {
+		p = filepath.Join(p, part)
+		if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("unsafe path %s in tar, %s is a symlink", name, p)
+		}
+	}
+
+	return nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+
+	return f.Chmod(mode)
+}
+
+// viaPushTar copies the From.Path from the source host to the target hosts by the tar streams,
+// the entries are filtered while passing through the local.
+func (cp *Scp) viaPushTar(fclient *Connect, tclients []*Connect, dst string) {
+	fow := fclient.Output.NewWriter()
+
+	for _, p := range cp.From.Path {
+		var servers []string
+		for _, tc := range tclients {
+			servers = append(servers, tc.Server)
+		}
+
+		event := audit.Start(audit.ActionScpCopy, append([]string{fclient.Server}, servers...)...)
+		event.Source, event.Target = fclient.Server+":"+p, strings.Join(servers, ",")+":"+dst
+
+		files, size, err := cp.viaPushTarPath(fclient, tclients, p, dst)
+		event.Size = size
+		event.End(err)
+
+		if err != nil {
+			fmt.Fprintf(fow, "tar %s error %v\n", p, err)
+			cp.Progress.Fail(fclient.Server, p, err)
+			continue
+		}
+
+		fmt.Fprintf(fow, "tar %s done, %d files, %s\n", p, files, ss.IBytes(uint64(size)))
+	}
+}
+
+func (cp *Scp) viaPushTarPath(fclient *Connect, tclients []*Connect, p, dst string) (files int, size int64, err error) {
+	src, err := fclient.SSH.NewSession()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer src.Close()
+
+	stdout, err := src.StdoutPipe()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := src.Start(cp.createCommand(p)); err != nil {
+		return 0, 0, err
+	}
+
+	r, err := cp.decompressReader(cp.Run.RateLimit().Reader(fclient.Server, stdout))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer r.Close()
+
+	var sessions []*ssh.Session
+	var writers []io.Writer
+	var closers []io.Closer
+
+	for _, tc := range tclients {
+		s, err := tc.SSH.NewSession()
+		if err != nil {
+			return 0, 0, err
+		}
+
+		defer s.Close()
+
+		stdin, err := s.StdinPipe()
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if err := s.Start(cp.extractCommand(dst)); err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", tc.Server, err)
+		}
+
+		cw, err := cp.compressWriter(stdin)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		sessions = append(sessions, s)
+		writers = append(writers, cw)
+		closers = append(closers, cw, stdin)
+	}
+
+	files, size, err = copyTar(r, io.MultiWriter(writers...), cp.Filter)
+
+	for _, c := range closers {
+		_ = c.Close()
+	}
+
+	for i, s := range sessions {
+		if e := s.Wait(); err == nil && e != nil {
+			err = fmt.Errorf("%s: %w", tclients[i].Server, e)
+		}
+	}
+
+	if e := src.Wait(); err == nil && e != nil {
+		err = e
+	}
+
+	return files, size, err
+}
+
+// copyTar copies the tar stream of r to w, the entries are filtered.
+func copyTar(r io.Reader, w io.Writer, filter *common.Filter) (files int, size int64, err error) {
+	tr, tw := tar.NewReader(r), tar.NewWriter(w)
+	skipper := &tarSkipper{filter: filter}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, size, tw.Close()
+		} else if err != nil {
+			return files, size, err
+		}
+
+		if skipper.skip(path.Clean(hdr.Name), hdr.Typeflag == tar.TypeDir) {
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return files, size, err
+		}
+
+		n, err := io.Copy(tw, tr)
+		if err != nil {
+			return files, size, err
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			files, size = files+1, size+n
+		}
+	}
+}
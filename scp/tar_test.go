@@ -0,0 +1,111 @@
+package scp
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTar(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	root := filepath.Join(src, "app")
+
+	for p, content := range map[string]string{"a.txt": "a", "sub/b.txt": "bb", "logs/c.log": "c", ".env": "e"} {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(root, p), []byte(content), 0o600))
+	}
+
+	assert.Nil(t, os.Symlink("a.txt", filepath.Join(root, "link")))
+
+	files, err := common.WalkDirFilter(root, &common.Filter{Hidden: true})
+	assert.Nil(t, err)
+	sort.Strings(files)
+
+	cp := &Scp{Compress: compressGzip}
+
+	var buf bytes.Buffer
+	cw, _ := cp.compressWriter(&buf)
+	assert.Nil(t, writeTar(cw, []PathSet{{Base: src, PathSlice: files}}))
+	assert.Nil(t, cw.Close())
+
+	assert.Equal(t, "tar -c -f - -C '/data' 'app' | gzip -q -c", cp.createCommand("/data/app"))
+	assert.Equal(t, "mkdir -p ~/'my app' && gzip -q -dc | tar -x --no-same-owner -f - -C ~/'my app'", cp.extractCommand("~/my app"))
+
+	r, err := cp.decompressReader(&buf)
+	assert.Nil(t, err)
+
+	n, size, err := extractTar(r, dst, &common.Filter{Exclude: []string{"logs/"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(3), size)
+
+	data, _ := os.ReadFile(filepath.Join(dst, "app/sub/b.txt"))
+	assert.Equal(t, "bb", string(data))
+
+	stat, _ := os.Stat(filepath.Join(dst, "app/a.txt"))
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+	link, _ := os.Readlink(filepath.Join(dst, "app/link"))
+	assert.Equal(t, "a.txt", link)
+
+	for _, excluded := range []string{"app/logs", "app/.env"} {
+		_, err := os.Lstat(filepath.Join(dst, excluded))
+		assert.True(t, os.IsNotExist(err), excluded)
+	}
+
+	_, _, err = extractTar(bytes.NewReader(unsafeTar(t)), dst, nil)
+	assert.NotNil(t, err)
+}
+
+func TestExtractTarSymlink(t *testing.T) {
+	dst, outside := t.TempDir(), t.TempDir()
+
+	// app/file is extracted as a regular file, replacing the symlink, not writing to outside/file.
+	safe := symlinkTar(t, outside, "app/file")
+	_, _, err := extractTar(bytes.NewReader(safe), dst, nil)
+	assert.Nil(t, err)
+
+	fi, err := os.Lstat(filepath.Join(dst, "app", "file"))
+	assert.Nil(t, err)
+	assert.True(t, fi.Mode().IsRegular())
+
+	// app/file/passwd is rejected, its parent is a symlink to outside.
+	unsafe := symlinkTar(t, outside, "app/file/passwd")
+	_, _, err = extractTar(bytes.NewReader(unsafe), t.TempDir(), nil)
+	assert.NotNil(t, err)
+
+	entries, _ := os.ReadDir(outside)
+	assert.Empty(t, entries)
+}
+
+// symlinkTar returns the tar with the symlink app/file -> outside, followed by the regular file name.
+func symlinkTar(t *testing.T, outside, name string) []byte {
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "app/", Mode: 0o755}))
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "app/file", Linkname: outside}))
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: 1}))
+	_, err := tw.Write([]byte("x"))
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+// unsafeTar returns the tar with the entry ../evil.
+func unsafeTar(t *testing.T) []byte {
+	f := filepath.Join(t.TempDir(), "evil")
+	assert.Nil(t, os.WriteFile(f, []byte("x"), 0o644))
+
+	var buf bytes.Buffer
+	assert.Nil(t, writeTar(&buf, []PathSet{{Base: filepath.Join(filepath.Dir(f), "sub"), PathSlice: []string{f}}}))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,102 @@
+package sftp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StartBatch runs the commands non-interactively like sftp -b, instead of the shell.
+// It stops on the first failed command, unless the command is prefixed by -, and returns its error.
+func (r *RunSftp) StartBatch(confpath string, commands []string) error {
+	r.connect(confpath)
+
+	if len(r.Client) != len(r.Run.ServerList) || len(r.Client) == 0 {
+		return fmt.Errorf("%d of %d servers failed to connect", len(r.Run.ServerList)-len(r.Client), len(r.Run.ServerList))
+	}
+
+	return r.batch(commands)
+}
+
+func (r *RunSftp) batch(commands []string) error {
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		if command == "" || strings.HasPrefix(command, "#") {
+			continue
+		}
+
+		fmt.Printf("bssh ftp>> %s\n", command)
+
+		ignore := strings.HasPrefix(command, "-")
+		if ignore {
+			command = strings.TrimSpace(command[1:])
+		}
+
+		if err := r.run(command); err != nil && !ignore {
+			return fmt.Errorf("command failed: %s", command)
+		}
+	}
+
+	return nil
+}
+
+// ReadBatch reads the commands of the batch file, one per line, - for stdin.
+func ReadBatch(file string) ([]string, error) {
+	var r io.Reader = os.Stdin
+
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	var commands []string
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		commands = append(commands, sc.Text())
+	}
+
+	return commands, sc.Err()
+}
+
+// SplitCommands splits the commands by ;, except the quoted ones.
+func SplitCommands(s string) []string {
+	var (
+		commands []string
+		b        strings.Builder
+		quote    rune
+		escaped  bool
+	)
+
+	for _, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			commands = append(commands, b.String())
+			b.Reset()
+
+			continue
+		}
+
+		b.WriteRune(c)
+	}
+
+	return append(commands, b.String())
+}
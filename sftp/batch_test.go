@@ -0,0 +1,55 @@
+package sftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingoohuang/bssh/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommands(t *testing.T) {
+	assert.Equal(t, []string{"cd /opt", " put 'a;b' /tmp", ` !echo "x;y" \; z`},
+		SplitCommands(`cd /opt; put 'a;b' /tmp; !echo "x;y" \; z`))
+	assert.Equal(t, []string{"pwd"}, SplitCommands("pwd"))
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	r := &RunSftp{}
+
+	assert.Nil(t, r.batch([]string{"# comment", "", "lmkdir " + filepath.Join(dir, "a"), "-nope"}))
+
+	err := r.batch([]string{"nope", "lmkdir " + filepath.Join(dir, "b")})
+	assert.EqualError(t, err, "command failed: nope")
+
+	_, err = os.Stat(filepath.Join(dir, "a"))
+	assert.Nil(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "b"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NotNil(t, r.batch([]string{"lcd /nonexistent"}))
+
+	f := filepath.Join(dir, "batch.txt")
+	assert.Nil(t, os.WriteFile(f, []byte("lpwd\n-lcd /nonexistent\n"), 0o644))
+
+	commands, err := ReadBatch(f)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"lpwd", "-lcd /nonexistent"}, commands)
+}
+
+func TestBatchRemote(t *testing.T) {
+	c := newMemConnect(t)
+	c.Output = &output.Output{Templete: oprompt, ServerList: []string{"web01"}}
+	writeMemFile(t, c, "/a.txt", "a")
+
+	r := &RunSftp{Client: map[string]*Connect{"web01": c}}
+
+	assert.Nil(t, r.batch([]string{"cat /a.txt", "tree /", "-cat /none", "-tree /none"}))
+	assert.EqualError(t, r.batch([]string{"cat /a.txt /none"}), "command failed: cat /a.txt /none")
+	assert.EqualError(t, r.batch([]string{"tree /none"}), "command failed: tree /none")
+	assert.EqualError(t, r.batch([]string{"cd /a.txt"}), "command failed: cd /a.txt")
+	assert.Equal(t, "/", c.Pwd)
+}
@@ -10,6 +10,8 @@ import (
 	"os"
 	"sort"
 
+	"github.com/bingoohuang/bssh/common"
+	"github.com/c-bata/go-prompt"
 	"github.com/urfave/cli"
 )
 
@@ -70,6 +72,36 @@ const (
 	`
 )
 
+// transferFlags is the options of get and put.
+var transferFlags = append([]cli.Flag{
+	cli.BoolFlag{Name: "resume", Usage: "continue from the existing destination files, if their prefixes are identical"},
+	cli.StringFlag{Name: "verify", Usage: "verify the files after transfer by `sha256|md5`"},
+}, common.FilterFlags(true)...)
+
+// transferSuggests is the suggests of transferFlags.
+var transferSuggests = []prompt.Suggest{
+	{Text: "--resume", Description: "continue from the existing destination files"},
+	{Text: "--verify", Description: "verify the files after transfer by sha256|md5"},
+	{Text: "--exclude", Description: "exclude the files matching pattern, like *.log or node_modules/"},
+	{Text: "--include", Description: "do not exclude the files matching pattern"},
+	{Text: "--exclude-from", Description: "read the exclude patterns from file, in gitignore syntax"},
+	{Text: "--gitignore", Description: "honour the .gitignore files in the local source directories"},
+	{Text: "--hidden", Description: "include the hidden files"},
+}
+
+// parseTransferFlags parses the transferFlags into the Option and Filter.
+func (r *RunSftp) parseTransferFlags(c *cli.Context) (err error) {
+	verify, err := common.ParseVerify(c.String("verify"))
+	if err != nil {
+		return err
+	}
+
+	r.Option = common.TransferOption{Resume: c.Bool("resume"), Verify: verify}
+	r.Filter, err = common.ParseFilter(c)
+
+	return err
+}
+
 // sftpLsData struct by sftp ls command list data.
 type sftpLsData struct {
 	Mode  string
@@ -0,0 +1,93 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/urfave/cli"
+)
+
+// cat prints the remote files.
+func (r *RunSftp) cat(args []string) error {
+	app := cli.NewApp()
+	app.CustomAppHelpTemplate = helptext
+	app.Name = "cat"
+	app.Usage = "bssh ftp build-in command: cat [remote machine cat]"
+	app.ArgsUsage = "[PATH...]"
+	app.HideHelp = true
+	app.HideVersion = true
+	app.EnableBashCompletion = true
+	app.Action = r.catAction
+
+	// parse short options
+	args = common.ParseArgs(app.Flags, args)
+
+	return app.Run(args)
+}
+
+func (r *RunSftp) catAction(c *cli.Context) error {
+	if len(c.Args()) == 0 {
+		err := errorf(os.Stdout, "Requires at least one argument\n")
+		fmt.Println("cat [path...]")
+
+		return err
+	}
+
+	return r.parallel(func(server string, client *Connect) (failed error) {
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
+		defer w.Close()
+
+		for _, path := range c.Args() {
+			// set arg path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(client.Pwd, path)
+			}
+
+			if err := catFile(client, path, w); err != nil {
+				failed = errorf(w, "%s\n", err)
+			}
+		}
+
+		return failed
+	})
+}
+
+// catFile copies the remote file to w, a newline is added if the file does not end with it.
+func catFile(client *Connect, path string, w io.Writer) error {
+	f, err := client.Connect.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	lw := &lastByteWriter{Writer: w}
+	if _, err := io.Copy(lw, f); err != nil {
+		return err
+	}
+
+	if lw.last != 0 && lw.last != '\n' {
+		_, err = w.Write([]byte("\n"))
+	}
+
+	return err
+}
+
+// lastByteWriter records the last byte written.
+type lastByteWriter struct {
+	io.Writer
+	last byte
+}
+
+func (w *lastByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.last = p[len(p)-1]
+	}
+
+	return w.Writer.Write(p)
+}
@@ -0,0 +1,53 @@
+package sftp
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMemConnect returns the Connect of an in-memory sftp server.
+func newMemConnect(t *testing.T) *Connect {
+	c1, c2 := net.Pipe()
+
+	server := sftp.NewRequestServer(c1, sftp.InMemHandler())
+	go func() { _ = server.Serve() }()
+
+	client, err := sftp.NewClientPipe(c2, c2)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return &Connect{Connect: client, Pwd: "/"}
+}
+
+// writeMemFile writes the file of the in-memory sftp server.
+func writeMemFile(t *testing.T, c *Connect, path, content string) {
+	f, err := c.Connect.Create(path)
+	assert.Nil(t, err)
+
+	_, err = f.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+}
+
+func TestCatFile(t *testing.T) {
+	c := newMemConnect(t)
+	writeMemFile(t, c, "/a.txt", "hello")
+	writeMemFile(t, c, "/b.txt", "world\n")
+	writeMemFile(t, c, "/empty", "")
+
+	var b strings.Builder
+	for _, path := range []string{"/a.txt", "/b.txt", "/empty"} {
+		assert.Nil(t, catFile(c, path, &b))
+	}
+
+	assert.Equal(t, "hello\nworld\n", b.String())
+	assert.NotNil(t, catFile(c, "/none", &b))
+}
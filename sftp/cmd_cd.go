@@ -7,7 +7,6 @@
 package sftp
 
 import (
-	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -15,7 +14,7 @@ import (
 
 // NOTE: カレントディレクトリの移動の仕組みを別途作成すること(保持する仕組みがないので).
 // cd change remote machine current directory.
-func (r *RunSftp) cd(args []string) {
+func (r *RunSftp) cd(args []string) error {
 	path := "./"
 	// cd command only
 	if len(args) == 1 {
@@ -24,11 +23,11 @@ func (r *RunSftp) cd(args []string) {
 			c.Pwd = path
 		}
 
-		return
+		return nil
 	}
 
 	// check directory
-	var okcounter int
+	var failed error
 
 	for server, client := range r.Client {
 		// get output
@@ -53,31 +52,30 @@ func (r *RunSftp) cd(args []string) {
 		// get stat
 		stat, err := client.Connect.Lstat(path)
 		if err != nil {
-			fmt.Fprintf(w, "Error: %s\n", err)
+			failed = errorf(w, "Error: %s\n", err)
 			continue
 		}
 
 		if !stat.IsDir() {
-			fmt.Fprintf(w, "Error: %s\n", "is not directory")
+			failed = errorf(w, "Error: %s\n", "is not directory")
 			continue
 		}
-
-		okcounter++
 	}
 
-	// check count okcounter
-	if okcounter != len(r.Client) {
-		return
+	if failed != nil {
+		return failed
 	}
 
 	// set pwd
 	for _, c := range r.Client {
 		c.Pwd = path
 	}
+
+	return nil
 }
 
 // lcd ...
-func (r *RunSftp) lcd(args []string) {
+func (r *RunSftp) lcd(args []string) error {
 	// get user home directory path
 	usr, _ := user.Current()
 
@@ -86,8 +84,9 @@ func (r *RunSftp) lcd(args []string) {
 		path = args[1]
 	}
 
-	err := os.Chdir(path)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	if err := os.Chdir(path); err != nil {
+		return errorf(os.Stderr, "%s\n", err)
 	}
+
+	return nil
 }
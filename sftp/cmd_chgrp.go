@@ -8,6 +8,7 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 
@@ -18,7 +19,7 @@ import (
 )
 
 // chgrp ...
-func (r *RunSftp) chgrp(args []string) {
+func (r *RunSftp) chgrp(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -35,35 +36,25 @@ func (r *RunSftp) chgrp(args []string) {
 
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) chgrpAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("chgrp group path")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
+	return r.parallel(func(server string, client *Connect) error {
 		group, path := c.Args()[0], c.Args()[1]
 
-		go r.doChgrp(exit, cl, s, path, group)
-	}
-
-	for range r.Client {
-		<-exit
-	}
-
-	return nil
+		return r.doChgrp(client, server, path, group)
+	})
 }
 
-func (r *RunSftp) doChgrp(exit chan bool, client *Connect, server, path, group string) {
-	defer func() { exit <- true }()
-
+func (r *RunSftp) doChgrp(client *Connect, server, path, group string) error {
 	// get writer
 	client.Output.Create(server)
 	w := client.Output.NewWriter()
@@ -80,14 +71,12 @@ func (r *RunSftp) doChgrp(exit chan bool, client *Connect, server, path, group s
 	if err != nil {
 		groups, err := ClientReadFile(client, "/etc/group")
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", err)
-			return
+			return errorf(w, "%s\n", err)
 		}
 
 		gid32, err := common.GetIDFromName(groups, group)
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", err)
-			return
+			return errorf(w, "%s\n", err)
 		}
 
 		gid = int(gid32)
@@ -98,8 +87,7 @@ func (r *RunSftp) doChgrp(exit chan bool, client *Connect, server, path, group s
 	// ge`t current uid
 	stat, err := client.Connect.Lstat(path)
 	if err != nil {
-		fmt.Fprintf(w, "%s\n", err)
-		return
+		return errorf(w, "%s\n", err)
 	}
 
 	if fstat, ok := stat.Sys().(*sftp.FileStat); ok {
@@ -108,9 +96,10 @@ func (r *RunSftp) doChgrp(exit chan bool, client *Connect, server, path, group s
 
 	// set gid
 	if err = client.Connect.Chown(path, uid, gid); err != nil {
-		fmt.Fprintf(w, "%s\n", err)
-		return
+		return errorf(w, "%s\n", err)
 	}
 
 	fmt.Fprintf(w, "chgrp: set %s's group as %s\n", path, group)
+
+	return nil
 }
@@ -17,7 +17,7 @@ import (
 )
 
 // chmod ...
-func (r *RunSftp) chmod(args []string) {
+func (r *RunSftp) chmod(args []string) error {
 	app := cli.NewApp()
 
 	app.CustomAppHelpTemplate = helptext
@@ -32,58 +32,44 @@ func (r *RunSftp) chmod(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) chmodAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("chmod mode path")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		server, client := s, cl
-
+	return r.parallel(func(server string, client *Connect) error {
 		mode, path := c.Args()[0], c.Args()[1]
 
-		go func() {
-			defer func() { exit <- true }()
-
-			// get writer
-			client.Output.Create(server)
-			w := client.Output.NewWriter()
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
 
-			// set arg path
-			if !filepath.IsAbs(path) {
-				path = filepath.Join(client.Pwd, path)
-			}
+		// set arg path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(client.Pwd, path)
+		}
 
-			// get mode
-			modeint, err := strconv.ParseUint(mode, 8, 32)
-			if err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return
-			}
+		// get mode
+		modeint, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return errorf(w, "%s\n", err)
+		}
 
-			filemode := os.FileMode(modeint)
+		filemode := os.FileMode(modeint)
 
-			// set filemode
-			if err = client.Connect.Chmod(path, filemode); err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return
-			}
+		// set filemode
+		if err = client.Connect.Chmod(path, filemode); err != nil {
+			return errorf(w, "%s\n", err)
+		}
 
-			fmt.Fprintf(w, "chmod: set %s's permission as %o(%s)\n", path, filemode.Perm(), filemode.String())
-		}()
-	}
-
-	for range r.Client {
-		<-exit
-	}
+		fmt.Fprintf(w, "chmod: set %s's permission as %o(%s)\n", path, filemode.Perm(), filemode.String())
 
-	return nil
+		return nil
+	})
 }
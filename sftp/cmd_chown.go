@@ -8,6 +8,7 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 
@@ -18,7 +19,7 @@ import (
 )
 
 // chown ...
-func (r *RunSftp) chown(args []string) {
+func (r *RunSftp) chown(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -35,36 +36,25 @@ func (r *RunSftp) chown(args []string) {
 
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) chownAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("chown group path")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		server, client := s, cl
+	return r.parallel(func(server string, client *Connect) error {
 		user, path := c.Args()[0], c.Args()[1]
 
-		go r.doChown(exit, client, server, path, user)
-	}
-
-	for range r.Client {
-		<-exit
-	}
-
-	return nil
+		return r.doChown(client, server, path, user)
+	})
 }
 
-func (r *RunSftp) doChown(exit chan bool, client *Connect, server string, path string, user string) {
-	defer func() { exit <- true }()
-
+func (r *RunSftp) doChown(client *Connect, server string, path string, user string) error {
 	// get writer
 	client.Output.Create(server)
 	w := client.Output.NewWriter()
@@ -82,15 +72,13 @@ func (r *RunSftp) doChown(exit chan bool, client *Connect, server string, path s
 		// read /etc/passwd
 		passwd, err := ClientReadFile(client, "/etc/passwd")
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", err)
-			return
+			return errorf(w, "%s\n", err)
 		}
 
 		// get gid
 		uid32, err := common.GetIDFromName(passwd, user)
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", err)
-			return
+			return errorf(w, "%s\n", err)
 		}
 
 		uid = int(uid32)
@@ -101,8 +89,7 @@ func (r *RunSftp) doChown(exit chan bool, client *Connect, server string, path s
 	// get current uid
 	stat, err := client.Connect.Lstat(path)
 	if err != nil {
-		fmt.Fprintf(w, "%s\n", err)
-		return
+		return errorf(w, "%s\n", err)
 	}
 
 	if fstat, ok := stat.Sys().(*sftp.FileStat); ok {
@@ -111,9 +98,10 @@ func (r *RunSftp) doChown(exit chan bool, client *Connect, server string, path s
 
 	// set gid
 	if err := client.Connect.Chown(path, uid, gid); err != nil {
-		fmt.Fprintf(w, "%s\n", err)
-		return
+		return errorf(w, "%s\n", err)
 	}
 
 	fmt.Fprintf(w, "chown: set %s's user as %s\n", path, user)
+
+	return nil
 }
@@ -0,0 +1,224 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/pkg/sftp"
+	"github.com/urfave/cli"
+)
+
+// copy copies the file or directory from local(l:|local:) or remote(r:|remote:) to local or remote,
+// the path without the prefix is remote.
+func (r *RunSftp) copy(args []string) error {
+	app := cli.NewApp()
+	app.CustomAppHelpTemplate = helptext
+	app.Name = "copy"
+	app.Usage = "bssh ftp build-in command: copy [copy file from remote or local to remote or local]"
+	app.ArgsUsage = "[[l:|r:]source [l:|r:]target]"
+	app.HideHelp = true
+	app.HideVersion = true
+	app.EnableBashCompletion = true
+	app.Action = r.copyAction
+
+	// parse short options
+	args = common.ParseArgs(app.Flags, args)
+
+	return app.Run(args)
+}
+
+func (r *RunSftp) copyAction(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		err := errorf(os.Stdout, "Requires two arguments\n")
+		fmt.Println("copy [l:|r:]source [l:|r:]target")
+
+		return err
+	}
+
+	srcRemote, source := parseCopyPath(c.Args()[0])
+	dstRemote, target := parseCopyPath(c.Args()[1])
+
+	switch {
+	case !srcRemote && dstRemote:
+		return r.put([]string{misc.Put, source, target})
+	case srcRemote && !dstRemote:
+		return r.get([]string{misc.Get, source, target})
+	case !srcRemote && !dstRemote:
+		if err := copyPath(localCopyFS{}, ss.ExpandHome(source), ss.ExpandHome(target)); err != nil {
+			return errorf(os.Stdout, "%s\n", err)
+		}
+
+		fmt.Printf("copy: %s -> %s\n", source, target)
+
+		return nil
+	default:
+		return r.copyRemote(source, target)
+	}
+}
+
+// copyRemote copies source to target on each remote host.
+func (r *RunSftp) copyRemote(source, target string) error {
+	return r.parallel(func(server string, client *Connect) error {
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
+		defer w.Close()
+
+		src, dst := source, target
+
+		// set arg path
+		if !filepath.IsAbs(src) {
+			src = filepath.Join(client.Pwd, src)
+		}
+
+		if !filepath.IsAbs(dst) {
+			dst = filepath.Join(client.Pwd, dst)
+		}
+
+		if err := copyPath(remoteCopyFS{client.Connect}, src, dst); err != nil {
+			return errorf(w, "%s\n", err)
+		}
+
+		fmt.Fprintf(w, "copy: %s -> %s\n", src, dst)
+
+		return nil
+	})
+}
+
+// parseCopyPath parses the l:, local:, r: or remote: prefix of the copy path, remote by default.
+func parseCopyPath(arg string) (isRemote bool, path string) {
+	if prefix, rest, ok := strings.Cut(arg, ":"); ok {
+		switch strings.ToLower(prefix) {
+		case "l", "local":
+			return false, rest
+		case "r", "remote":
+			return true, rest
+		}
+	}
+
+	return true, arg
+}
+
+// copyFS is the local or remote file system of copy.
+type copyFS interface {
+	Stat(path string) (os.FileInfo, error)
+	ReadDir(path string) ([]os.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	Create(path string, mode os.FileMode) (io.WriteCloser, error)
+	MkdirAll(path string) error
+}
+
+// copyPath copies the file or directory src to dst, into dst if it is an existing directory.
+func copyPath(fs copyFS, src, dst string) error {
+	stat, err := fs.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if ds, err := fs.Stat(dst); err == nil && ds.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+
+	if src, dst = filepath.Clean(src), filepath.Clean(dst); src == dst || strings.HasPrefix(dst, src+"/") {
+		return fmt.Errorf("cannot copy %s to itself %s", src, dst)
+	}
+
+	return copyEntry(fs, src, dst, stat)
+}
+
+func copyEntry(fs copyFS, src, dst string, stat os.FileInfo) error {
+	if !stat.IsDir() {
+		return copyFile(fs, src, dst, stat.Mode().Perm())
+	}
+
+	if err := fs.MkdirAll(dst); err != nil {
+		return err
+	}
+
+	list, err := fs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range list {
+		if err := copyEntry(fs, filepath.Join(src, f.Name()), filepath.Join(dst, f.Name()), f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(fs copyFS, src, dst string, mode os.FileMode) error {
+	rf, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer rf.Close()
+
+	wf, err := fs.Create(dst, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(wf, rf); err != nil {
+		wf.Close()
+		return err
+	}
+
+	return wf.Close()
+}
+
+type localCopyFS struct{}
+
+func (localCopyFS) Stat(path string) (os.FileInfo, error) { return os.Stat(path) }
+
+func (localCopyFS) ReadDir(path string) ([]os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return f.Readdir(-1)
+}
+
+func (localCopyFS) Open(path string) (io.ReadCloser, error) { return os.Open(path) }
+
+func (localCopyFS) Create(path string, mode os.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+}
+
+func (localCopyFS) MkdirAll(path string) error { return os.MkdirAll(path, 0o755) }
+
+type remoteCopyFS struct{ c *sftp.Client }
+
+func (f remoteCopyFS) Stat(path string) (os.FileInfo, error) { return f.c.Stat(path) }
+
+func (f remoteCopyFS) ReadDir(path string) ([]os.FileInfo, error) { return f.c.ReadDir(path) }
+
+func (f remoteCopyFS) Open(path string) (io.ReadCloser, error) { return f.c.Open(path) }
+
+func (f remoteCopyFS) Create(path string, mode os.FileMode) (io.WriteCloser, error) {
+	wf, err := f.c.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wf.Chmod(mode); err != nil {
+		wf.Close()
+		return nil, err
+	}
+
+	return wf, nil
+}
+
+func (f remoteCopyFS) MkdirAll(path string) error { return f.c.MkdirAll(path) }
@@ -0,0 +1,53 @@
+package sftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCopyPath(t *testing.T) {
+	for arg, want := range map[string]struct {
+		remote bool
+		path   string
+	}{
+		"l:/tmp/a":      {false, "/tmp/a"},
+		"local:~/a":     {false, "~/a"},
+		"L:a":           {false, "a"},
+		"r:/opt/a":      {true, "/opt/a"},
+		"remote:/opt/a": {true, "/opt/a"},
+		"/opt/a":        {true, "/opt/a"},
+		"x:/opt/a":      {true, "x:/opt/a"},
+	} {
+		remote, path := parseCopyPath(arg)
+		assert.Equal(t, want.remote, remote, arg)
+		assert.Equal(t, want.path, path, arg)
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(src, "a.sh"), []byte("a"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0o644))
+
+	// the directory is copied as dst.
+	assert.Nil(t, copyPath(localCopyFS{}, src, filepath.Join(dir, "dst")))
+
+	data, _ := os.ReadFile(filepath.Join(dir, "dst", "sub", "b.txt"))
+	assert.Equal(t, "b", string(data))
+
+	stat, _ := os.Stat(filepath.Join(dir, "dst", "a.sh"))
+	assert.Equal(t, os.FileMode(0o755), stat.Mode().Perm())
+
+	// the file is copied into the existing directory.
+	assert.Nil(t, copyPath(localCopyFS{}, filepath.Join(src, "a.sh"), filepath.Join(dir, "dst", "sub")))
+	assert.FileExists(t, filepath.Join(dir, "dst", "sub", "a.sh"))
+
+	assert.NotNil(t, copyPath(localCopyFS{}, src, filepath.Join(src, "sub")), "copy into itself")
+	assert.NotNil(t, copyPath(localCopyFS{}, filepath.Join(dir, "none"), filepath.Join(dir, "x")))
+}
@@ -23,7 +23,7 @@ import (
 )
 
 // df exec and print out remote df.
-func (r *RunSftp) df(args []string) {
+func (r *RunSftp) df(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -46,13 +46,13 @@ func (r *RunSftp) df(args []string) {
 
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) dfAction(c *cli.Context) error {
 	argpath := c.Args().First()
 
-	stats := r.getRemoteStat(argpath)
+	stats, err := r.getRemoteStat(argpath)
 
 	// set tabwriter
 	tabw := new(tabwriter.Writer)
@@ -74,7 +74,7 @@ func (r *RunSftp) dfAction(c *cli.Context) error {
 	// write tabwriter
 	tabw.Flush()
 
-	return nil
+	return err
 }
 
 func (r *RunSftp) setDataInColumns(c *cli.Context, stat *sftp.StatVFS, server string, tabw io.Writer) {
@@ -119,9 +119,9 @@ func (r *RunSftp) setDataInColumns(c *cli.Context, stat *sftp.StatVFS, server st
 	fmt.Fprintf(tabw, "%s\t%s\t%s\t%s\t%s%%\t\n", column1, column2, column3, column4, column5)
 }
 
-// getRemoteStat  get remote stat data.
-func (r *RunSftp) getRemoteStat(argpath string) map[string]*sftp.StatVFS {
-	stats := map[string]*sftp.StatVFS{}
+// getRemoteStat  get remote stat data, and the last error of the servers failed.
+func (r *RunSftp) getRemoteStat(argpath string) (stats map[string]*sftp.StatVFS, failed error) {
+	stats = map[string]*sftp.StatVFS{}
 
 	for server, client := range r.Client {
 		ftp := client.Connect
@@ -137,12 +137,12 @@ func (r *RunSftp) getRemoteStat(argpath string) map[string]*sftp.StatVFS {
 
 		stat, err := ftp.StatVFS(path)
 		if err != nil {
-			fmt.Println(err)
+			failed = errorf(os.Stdout, "%s\n", err)
 			continue
 		}
 
 		stats[server] = stat
 	}
 
-	return stats
+	return stats, failed
 }
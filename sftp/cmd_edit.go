@@ -0,0 +1,157 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/pkg/sftp"
+	"github.com/urfave/cli"
+)
+
+// edit edits the remote file in the local editor, on the servers one by one.
+func (r *RunSftp) edit(args []string) error {
+	app := cli.NewApp()
+	app.CustomAppHelpTemplate = helptext
+	app.Name = "edit"
+	app.Usage = "bssh ftp build-in command: edit [edit remote file by local $EDITOR]"
+	app.ArgsUsage = misc.Path
+	app.HideHelp = true
+	app.HideVersion = true
+	app.EnableBashCompletion = true
+	app.Action = r.editAction
+
+	// parse short options
+	args = common.ParseArgs(app.Flags, args)
+
+	return app.Run(args)
+}
+
+func (r *RunSftp) editAction(c *cli.Context) error {
+	if len(c.Args()) != 1 {
+		err := errorf(os.Stdout, "Requires one arguments\n")
+		fmt.Println("edit [path]")
+
+		return err
+	}
+
+	servers := make([]string, 0, len(r.Client))
+	for server := range r.Client {
+		servers = append(servers, server)
+	}
+
+	sort.Strings(servers)
+
+	var failed error
+
+	for _, server := range servers {
+		client := r.Client[server]
+
+		path := c.Args()[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(client.Pwd, path)
+		}
+
+		fmt.Printf("edit %s:%s\n", server, path)
+
+		event := audit.Start(audit.ActionEdit, server)
+		event.Target = path
+
+		err := editRemote(client.Connect, path)
+		event.End(err)
+
+		if err != nil {
+			failed = errorf(os.Stderr, "%s:%s %s\n", server, path, err)
+		}
+	}
+
+	return failed
+}
+
+// editRemote edits the remote file over sftp, it is written back in place so its mode and owner are kept.
+func editRemote(client *sftp.Client, path string) error {
+	stat, err := client.Stat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if stat != nil && !stat.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file")
+	}
+
+	saved, err := common.EditRemote(path,
+		func() ([]byte, error) { return readRemote(client, path) },
+		func(data []byte) error { return writeRemote(client, path, data, stat) })
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", path, ss.If(saved, "saved", "not changed"))
+
+	return nil
+}
+
+// readRemote reads the remote file, nil if not exists.
+func readRemote(client *sftp.Client, path string) ([]byte, error) {
+	f, err := client.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// writeRemote writes data to the remote file in place, and restores the mode and owner of stat if changed.
+func writeRemote(client *sftp.Client, path string, data []byte, stat os.FileInfo) error {
+	f, err := client.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if stat == nil {
+		return nil
+	}
+
+	now, err := client.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if now.Mode().Perm() != stat.Mode().Perm() {
+		if err := client.Chmod(path, stat.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	was, ok1 := stat.Sys().(*sftp.FileStat)
+	is, ok2 := now.Sys().(*sftp.FileStat)
+
+	if ok1 && ok2 && (was.UID != is.UID || was.GID != is.GID) {
+		return client.Chown(path, int(was.UID), int(was.GID))
+	}
+
+	return nil
+}
@@ -9,19 +9,19 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"sync"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/bssh/output"
 	"github.com/bingoohuang/ngg/ss"
 	"github.com/urfave/cli"
-	"github.com/vbauerster/mpb"
 )
 
 // TDXX(blacknon): リファクタリング(v0.6.1)
 
-func (r *RunSftp) get(args []string) {
+func (r *RunSftp) get(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -30,9 +30,10 @@ func (r *RunSftp) get(args []string) {
 	app.CustomAppHelpTemplate = helptext
 
 	// set parameter
+	app.Flags = transferFlags
 	app.Name = misc.Get
 	app.Usage = "bssh ftp build-in command: get"
-	app.ArgsUsage = "[source(remote) target(local)]"
+	app.ArgsUsage = "[--resume] [--verify sha256|md5] [source(remote) target(local)]"
 	app.HideHelp = true
 	app.HideVersion = true
 	app.EnableBashCompletion = true
@@ -41,10 +42,10 @@ func (r *RunSftp) get(args []string) {
 	app.Action = r.getAction
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
-func (r *RunSftp) pullPath(client *Connect, path, target string) {
+func (r *RunSftp) pullPath(client *Connect, path, target string) (failed error) {
 	// set arg path
 	var rpath string
 
@@ -61,7 +62,14 @@ func (r *RunSftp) pullPath(client *Connect, path, target string) {
 	ow := client.Output.NewWriter()
 
 	// expantion path
-	epath, _ := client.Connect.Glob(rpath)
+	epath, err := client.Connect.Glob(rpath)
+	if err != nil {
+		return errorf(ow, "Error: %s\n", err)
+	}
+
+	if len(epath) == 0 {
+		return errorf(ow, "Error: %s not found\n", rpath)
+	}
 
 	// for walk
 	for _, ep := range epath {
@@ -70,7 +78,7 @@ func (r *RunSftp) pullPath(client *Connect, path, target string) {
 		for walker.Step() {
 			err := walker.Err()
 			if err != nil {
-				fmt.Fprintf(ow, "Error: %s\n", err)
+				failed = errorf(ow, "Error: %s\n", err)
 				continue
 			}
 
@@ -78,32 +86,45 @@ func (r *RunSftp) pullPath(client *Connect, path, target string) {
 			relpath, _ := filepath.Rel(base, p)
 			stat := walker.Stat()
 
+			if rel, _ := filepath.Rel(ep, p); r.Filter.Excluded(rel, stat.IsDir()) {
+				if stat.IsDir() {
+					walker.SkipDir()
+				}
+
+				continue
+			}
+
 			localpath := filepath.Join(target, relpath)
 
 			//
 			if stat.IsDir() { // is directory
 				_ = os.Mkdir(localpath, 0o755)
 			} else if err := pullFile(stat, client, localpath, p, r); err != nil { // is not directory
-				fmt.Fprintf(ow, "Error: %s\n", err)
+				failed = errorf(ow, "Error: %s\n", err)
 				continue
 			}
 
 			_ = os.Chmod(localpath, stat.Mode())
 		}
 	}
+
+	return failed
 }
 
 func (r *RunSftp) getAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("get source(remote) target(local)")
 
-		return nil
+		return err
+	}
+
+	if err := r.parseTransferFlags(c); err != nil {
+		return errorf(os.Stdout, "%s\n", err)
 	}
 
 	// Create Progress
-	r.ProgressWG = new(sync.WaitGroup)
-	r.Progress = mpb.New(mpb.WithWaitGroup(r.ProgressWG))
+	r.Progress = output.NewProgress(false, os.Stdout)
 
 	// set path
 	source := c.Args()[0]
@@ -116,29 +137,24 @@ func (r *RunSftp) getAction(c *cli.Context) error {
 	target, _ = filepath.Abs(target)
 
 	// get directory data, copy remote to local
-	exit := make(chan bool)
-
-	for s, c := range r.Client {
-		server := s
+	targetdirs := map[string]string{}
 
+	for server := range r.Client {
 		targetdir := target
 		if len(r.Client) > 1 {
 			targetdir = filepath.Join(target, server)
 			// mkdir local target directory
-			err = os.MkdirAll(targetdir, 0o755)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-				return nil
+			if err := os.MkdirAll(targetdir, 0o755); err != nil {
+				return errorf(os.Stderr, "Error: %s\n", err)
 			}
 		}
 
-		go r.doGet(exit, c, server, source, targetdir)
+		targetdirs[server] = targetdir
 	}
 
-	// wait exit
-	for range r.Client {
-		<-exit
-	}
+	err = r.parallel(func(server string, client *Connect) error {
+		return r.doGet(client, server, source, targetdirs[server])
+	})
 
 	// wait Progress
 	r.Progress.Wait()
@@ -146,20 +162,17 @@ func (r *RunSftp) getAction(c *cli.Context) error {
 	// wait 0.3 sec
 	time.Sleep(300 * time.Millisecond)
 
-	return nil
+	return err
 }
 
-func (r *RunSftp) doGet(exit chan bool, client *Connect, server, source, targetdir string) {
-	defer func() { exit <- true }()
-
+func (r *RunSftp) doGet(client *Connect, server, source, targetdir string) error {
 	// set Progress
 	client.Output.Progress = r.Progress
-	client.Output.ProgressWG = r.ProgressWG
 
 	// create output
 	client.Output.Create(server)
 
-	r.pullPath(client, source, targetdir)
+	return r.pullPath(client, source, targetdir)
 }
 
 func (r *RunSftp) parseTarget(c *cli.Context) (string, error) {
@@ -168,24 +181,28 @@ func (r *RunSftp) parseTarget(c *cli.Context) (string, error) {
 	// get target directory abs
 	target, err := filepath.Abs(target)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		return "", err
+		return "", errorf(os.Stderr, "Error: %s\n", err)
 	}
 
 	// mkdir local target directory
 	err = os.MkdirAll(target, 0o755)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		return "", err
+		return "", errorf(os.Stderr, "Error: %s\n", err)
 	}
 
 	return target, nil
 }
 
-func pullFile(stat os.FileInfo, client *Connect, localpath, p string, r *RunSftp) error {
+func pullFile(stat os.FileInfo, client *Connect, localpath, p string, r *RunSftp) (err error) {
 	// get size
 	size := stat.Size()
 
+	server := client.Output.Server
+	event := audit.Start(audit.ActionSftpGet, server)
+	event.Source, event.Target, event.Size = server+":"+p, localpath, size
+
+	defer func() { event.End(err) }()
+
 	// open remote file
 	remotefile, err := client.Connect.Open(p)
 	if err != nil {
@@ -194,17 +211,32 @@ func pullFile(stat os.FileInfo, client *Connect, localpath, p string, r *RunSftp
 
 	defer remotefile.Close()
 
+	srcHash := common.RemoteHasher(client.SSH, client.Connect, r.Option.HashAlgo(), p)
+	dstHash := common.LocalHasher(r.Option.HashAlgo(), localpath)
+
+	var offset int64
+	if lstat, e := os.Stat(localpath); e == nil && r.Option.Resume {
+		offset = r.Option.ResumeOffset(size, lstat.Size(), srcHash, dstHash)
+	}
+
 	// open local file
-	localfile, err := os.OpenFile(localpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	localfile, err := os.OpenFile(localpath, common.OpenFlag(offset), 0o644)
 	if err != nil {
 		return err
 	}
 
 	defer localfile.Close()
 
+	if err = common.SeekResume(offset, remotefile, localfile, nil, localpath); err != nil {
+		return err
+	}
+
 	// set tee reader
-	rd := io.TeeReader(common.CreateRateLimit(remotefile), localfile)
+	rd := io.TeeReader(r.Run.RateLimit().Reader(server, remotefile), localfile)
+
+	if err = client.Output.ProgressPrinter(size-offset, rd, p); err != nil {
+		return err
+	}
 
-	r.ProgressWG.Add(1)
-	return client.Output.ProgressPrinter(size, rd, p)
+	return r.Option.VerifyHash(srcHash, dstHash)
 }
@@ -0,0 +1,85 @@
+package sftp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bingoohuang/bssh/misc"
+)
+
+// sftpCommand is a command of the sftp shell, for help and the suggests.
+type sftpCommand struct {
+	Name        string
+	Usage       string
+	Description string
+}
+
+// sftpCommands is the commands of the sftp shell.
+var sftpCommands = []sftpCommand{
+	{"bye", "bye", "Quit lsftp"},
+	{"cat", "cat path...", "Print remote files"},
+	{"cd", "cd [path]", "Change remote directory to 'path'"},
+	{misc.Chgrp, "chgrp grp path", "Change group of file 'path' to 'grp'"},
+	{"chmod", "chmod mode path", "Change permissions of file 'path' to 'mode'"},
+	{misc.Chown, "chown own path", "Change owner of file 'path' to 'own'"},
+	{"copy", "copy [l:|r:]source [l:|r:]target", "Copy file from 'remote' or 'local' to 'remote' or 'local', remote without prefix"},
+	{"df", "df [-hi] [path]", "Display statistics for current directory or filesystem containing 'path'"},
+	{"edit", "edit path", "Edit remote file by local $EDITOR, saved only if changed"},
+	{"exit", "exit", "Quit lsftp"},
+	{misc.Get, "get [options] remote [local]", "Download file"},
+	{"help", "help", "Display this help text"},
+	{"lcd", "lcd path", "Change local directory to 'path'"},
+	{misc.Lls, "lls [options] [path]", "Display local directory listing"},
+	{misc.Lmkdir, "lmkdir [-p] path", "Create local directory"},
+	{"ln", "ln [-s] source target", "Link remote file (-s for symlink)"},
+	{"lpwd", "lpwd", "Print local working directory"},
+	{"ls", "ls [options] [path]", "Display remote directory listing"},
+	{"lumask", "lumask [umask]", "Print or set local umask to 'umask'"},
+	{misc.Mkdir, "mkdir [-p] path", "Create remote directory"},
+	{misc.Put, "put [options] local [remote]", "Upload file"},
+	{"pwd", "pwd", "Display remote working directory"},
+	{"quit", "quit", "Quit lsftp"},
+	{misc.Rename, "rename oldpath newpath", "Rename remote file"},
+	{"rm", "rm [-r] path", "Delete remote file"},
+	{misc.Rmdir, "rmdir path", "Remove remote directory"},
+	{misc.Symlink, "symlink source target", "Create symbolic link"},
+	{"tree", "tree [-ad] [-L level] [path]", "Tree view remote directory"},
+	{"!", "!", "Escape to local shell"},
+	{"!command", "!command", "Execute 'command' in local shell"},
+	{"?", "?", "Display this help text"},
+}
+
+// help prints the commands with their usages.
+func (r *RunSftp) help() {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	for _, c := range sftpCommands {
+		fmt.Fprintf(tw, "%s\t%s\n", c.Usage, c.Description)
+	}
+
+	_ = tw.Flush()
+}
+
+// localShell runs the command in the local shell, or the interactive shell if command is empty.
+func (r *RunSftp) localShell(command string) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	cmd := exec.Command(shell)
+	if command = strings.TrimSpace(command); command != "" {
+		cmd = exec.Command(shell, "-c", command)
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return errorf(os.Stderr, "%s\n", err)
+	}
+
+	return nil
+}
@@ -24,7 +24,7 @@ import (
 )
 
 // lls exec and print out local ls data.
-func (r *RunSftp) lls(args []string) {
+func (r *RunSftp) lls(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -50,14 +50,14 @@ func (r *RunSftp) lls(args []string) {
 	app.HideHelp = true
 	app.HideVersion = true
 	app.EnableBashCompletion = true
-	app.Action = llsAction
+	app.Action = r.llsAction
 
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
-func llsAction(c *cli.Context) error {
+func (r *RunSftp) llsAction(c *cli.Context) error {
 	// argpath
 	argpath := c.Args().First()
 	if argpath == "" {
@@ -66,8 +66,7 @@ func llsAction(c *cli.Context) error {
 
 	stat, err := os.Stat(argpath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-		return nil
+		return errorf(os.Stderr, "%s\n", err)
 	}
 
 	// check is directory
@@ -75,8 +74,7 @@ func llsAction(c *cli.Context) error {
 
 	if stat.IsDir() {
 		if data, err = ioutil.ReadDir(argpath); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			return nil
+			return errorf(os.Stderr, "%s\n", err)
 		}
 	} else {
 		data = append(data, stat)
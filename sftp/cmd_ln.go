@@ -0,0 +1,72 @@
+package sftp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/urfave/cli"
+)
+
+// ln links the remote file, hard link by default, symbolic link with -s.
+func (r *RunSftp) ln(args []string) error {
+	app := cli.NewApp()
+	app.CustomAppHelpTemplate = helptext
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{Name: "s", Usage: "make symbolic links instead of hard links"},
+	}
+	app.Name = "ln"
+	app.Usage = "bssh ftp build-in command: ln [remote machine ln]"
+	app.ArgsUsage = "[source target]"
+	app.HideHelp = true
+	app.HideVersion = true
+	app.EnableBashCompletion = true
+	app.Action = r.lnAction
+
+	// parse short options
+	args = common.ParseArgs(app.Flags, args)
+
+	return app.Run(args)
+}
+
+func (r *RunSftp) lnAction(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		err := errorf(os.Stdout, "Requires two arguments\n")
+		fmt.Println("ln [-s] source target")
+
+		return err
+	}
+
+	return r.parallel(func(server string, client *Connect) error {
+		source := c.Args()[0]
+		target := c.Args()[1]
+
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
+		defer w.Close()
+
+		// set arg path, the relative symlink source is kept as is
+		if !filepath.IsAbs(source) && !c.Bool("s") {
+			source = filepath.Join(client.Pwd, source)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(client.Pwd, target)
+		}
+
+		link := client.Connect.Link
+		if c.Bool("s") {
+			link = client.Connect.Symlink
+		}
+
+		if err := link(source, target); err != nil {
+			return errorf(w, "%s\n", err)
+		}
+
+		fmt.Fprintf(w, "link: %s -> %s\n", target, source)
+
+		return nil
+	})
+}
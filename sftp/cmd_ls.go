@@ -86,7 +86,7 @@ func (r *RunSftp) getRemoteLsData(client *Connect, path string) (lsdata sftpLs,
 }
 
 // ls exec and print out remote ls data.
-func (r *RunSftp) ls(args []string) {
+func (r *RunSftp) ls(args []string) error {
 	app := cli.NewApp()
 	app.CustomAppHelpTemplate = helptext
 	app.Flags = []cli.Flag{
@@ -109,7 +109,7 @@ func (r *RunSftp) ls(args []string) {
 	app.Action = r.lsAction
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) lsAction(c *cli.Context) error {
@@ -117,20 +117,12 @@ func (r *RunSftp) lsAction(c *cli.Context) error {
 	argpath := c.Args().First()
 
 	// get directory files data
-	exit := make(chan bool)
 	lsdata := map[string]sftpLs{}
 	m := new(sync.Mutex)
 
-	for s, cl := range r.Client {
-		server, client := s, cl
-
-		go r.doLs(lsdata, c, exit, m, client, server, argpath)
-	}
-
-	// wait get directory data
-	for range r.Client {
-		<-exit
-	}
+	err := r.parallel(func(server string, client *Connect) error {
+		return r.doLs(lsdata, c, m, client, server, argpath)
+	})
 
 	switch {
 	case c.Bool("l"): // long list format
@@ -166,7 +158,7 @@ func (r *RunSftp) lsAction(c *cli.Context) error {
 		}
 	}
 
-	return nil
+	return err
 }
 
 func (r *RunSftp) longList(lsdata map[string]sftpLs, c *cli.Context) {
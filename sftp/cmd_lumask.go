@@ -0,0 +1,42 @@
+package sftp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// lumask prints or sets the local umask, which applies to the files created by get.
+func (r *RunSftp) lumask(args []string) error {
+	if len(args) > 2 {
+		err := errorf(os.Stdout, "Requires zero or one argument\n")
+		fmt.Println("lumask [umask]")
+
+		return err
+	}
+
+	if len(args) == 1 {
+		mask, err := umask(-1)
+		if err != nil {
+			return errorf(os.Stdout, "%s\n", err)
+		}
+
+		fmt.Printf("%04o\n", mask)
+
+		return nil
+	}
+
+	mask, err := strconv.ParseUint(args[1], 8, 32)
+	if err != nil || mask > 0o777 {
+		return errorf(os.Stdout, "invalid umask %s\n", args[1])
+	}
+
+	old, err := umask(int(mask))
+	if err != nil {
+		return errorf(os.Stdout, "%s\n", err)
+	}
+
+	fmt.Printf("local umask: %04o -> %04o\n", old, mask)
+
+	return nil
+}
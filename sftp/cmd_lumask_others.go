@@ -0,0 +1,17 @@
+//go:build !windows
+
+package sftp
+
+import "syscall"
+
+// umask sets the umask of the process if mask >= 0, and returns the old one.
+func umask(mask int) (int, error) {
+	if mask < 0 {
+		old := syscall.Umask(0)
+		syscall.Umask(old)
+
+		return old, nil
+	}
+
+	return syscall.Umask(mask), nil
+}
@@ -0,0 +1,8 @@
+package sftp
+
+import "errors"
+
+// umask is not supported on windows.
+func umask(int) (int, error) {
+	return 0, errors.New("lumask is not supported on windows")
+}
@@ -17,7 +17,7 @@ import (
 	"github.com/urfave/cli"
 )
 
-func (r *RunSftp) mkdir(args []string) {
+func (r *RunSftp) mkdir(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -42,61 +42,50 @@ func (r *RunSftp) mkdir(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) mkdirAction(c *cli.Context) error {
 	// TDXX(blacknon): 複数のディレクトリ受付(v0.6.1以降)
 	if len(c.Args()) != 1 {
-		fmt.Println("Requires one arguments")
+		err := errorf(os.Stdout, "Requires one arguments\n")
 		fmt.Println("mkdir [path]")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		server, client := s, cl
+	return r.parallel(func(server string, client *Connect) error {
 		path := c.Args()[0]
 
-		go func() {
-			defer func() { exit <- true }()
-
-			// get writer
-			client.Output.Create(server)
-			w := client.Output.NewWriter()
-
-			// set arg path
-			if !filepath.IsAbs(path) {
-				path = filepath.Join(client.Pwd, path)
-			}
-
-			// create directory
-			var err error
-			if c.Bool("p") {
-				err = client.Connect.MkdirAll(path)
-			} else {
-				err = client.Connect.Mkdir(path)
-			}
-
-			// check error
-			if err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-			}
-
-			fmt.Fprintf(w, "make directory: %s\n", path)
-		}()
-	}
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
 
-	for range r.Client {
-		<-exit
-	}
+		// set arg path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(client.Pwd, path)
+		}
 
-	return nil
+		// create directory
+		var err error
+		if c.Bool("p") {
+			err = client.Connect.MkdirAll(path)
+		} else {
+			err = client.Connect.Mkdir(path)
+		}
+
+		// check error
+		if err != nil {
+			return errorf(w, "%s\n", err)
+		}
+
+		fmt.Fprintf(w, "make directory: %s\n", path)
+
+		return nil
+	})
 }
 
-func (r *RunSftp) lmkdir(args []string) {
+func (r *RunSftp) lmkdir(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -119,10 +108,10 @@ func (r *RunSftp) lmkdir(args []string) {
 	app.Action = func(c *cli.Context) error {
 		// TDXX(blacknon): 複数のディレクトリ受付(v0.6.1以降)
 		if len(c.Args()) != 1 {
-			fmt.Println("Requires one arguments")
+			err := errorf(os.Stdout, "Requires one arguments\n")
 			fmt.Println("lmkdir [path]")
 
-			return nil
+			return err
 		}
 
 		path := c.Args()[0]
@@ -136,7 +125,7 @@ func (r *RunSftp) lmkdir(args []string) {
 		}
 
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return errorf(os.Stderr, "%s\n", err)
 		}
 
 		return nil
@@ -145,5 +134,5 @@ func (r *RunSftp) lmkdir(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
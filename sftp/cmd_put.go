@@ -10,23 +10,24 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"sync"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/bssh/output"
 	"github.com/bingoohuang/ngg/ss"
 	"github.com/urfave/cli"
-	"github.com/vbauerster/mpb"
 )
 
 // TDXX(blacknon): リファクタリング(v0.6.1).
-func (r *RunSftp) put(args []string) {
+func (r *RunSftp) put(args []string) error {
 	app := cli.NewApp()
+	app.Flags = transferFlags
 	app.CustomAppHelpTemplate = helptext
 	app.Name = misc.Put
 	app.Usage = "bssh ftp build-in command: put"
-	app.ArgsUsage = "[source(local) target(remote)]"
+	app.ArgsUsage = "[--resume] [--verify sha256|md5] [source(local) target(remote)]"
 	app.HideHelp = true
 	app.HideVersion = true
 	app.EnableBashCompletion = true
@@ -34,29 +35,31 @@ func (r *RunSftp) put(args []string) {
 
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) putAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("put source(local) target(remote)")
 
-		return nil
+		return err
+	}
+
+	if err := r.parseTransferFlags(c); err != nil {
+		return errorf(os.Stdout, "%s\n", err)
 	}
 
 	// Create Progress
-	r.ProgressWG = new(sync.WaitGroup)
-	r.Progress = mpb.New(mpb.WithWaitGroup(r.ProgressWG))
+	r.Progress = output.NewProgress(false, os.Stdout)
 
 	// set path
 	source := ss.ExpandHome(c.Args()[0])
 	target := c.Args()[1]
 
-	data, err := common.WalkDir(source)
+	data, err := common.WalkDirFilter(source, r.Filter)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-		return nil
+		return errorf(os.Stderr, "%s\n", err)
 	}
 
 	sort.Strings(data)
@@ -65,40 +68,28 @@ func (r *RunSftp) putAction(c *cli.Context) error {
 	pathSet := PathSet{Base: filepath.Dir(source), PathSlice: data}
 
 	// parallel push data
-	exit := make(chan bool)
-
-	for s, c := range r.Client {
-		server, client := s, c
-
-		go func() {
-			defer func() { exit <- true }()
-
-			client.Output.Progress = r.Progress
-			client.Output.ProgressWG = r.ProgressWG
+	err = r.parallel(func(server string, client *Connect) (failed error) {
+		client.Output.Progress = r.Progress
 
-			client.Output.Create(server)
+		client.Output.Create(server)
 
-			base := pathSet.Base
-			data := pathSet.PathSlice
+		base := pathSet.Base
+		data := pathSet.PathSlice
 
-			for _, path := range data {
-				if err := r.pushPath(client, target, base, path); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				}
+		for _, path := range data {
+			if err := r.pushPath(client, target, base, path); err != nil {
+				failed = errorf(os.Stderr, "Error: %v\n", err)
 			}
-		}()
-	}
+		}
 
-	// wait exit
-	for range r.Client {
-		<-exit
-	}
+		return failed
+	})
 
 	r.Progress.Wait() // wait Progress
 
 	time.Sleep(300 * time.Millisecond)
 
-	return nil
+	return err
 }
 
 func (r *RunSftp) pushPath(client *Connect, target, base, path string) (err error) {
@@ -122,7 +113,14 @@ func (r *RunSftp) pushPath(client *Connect, target, base, path string) (err erro
 
 		defer localFile.Close()
 
-		if err = r.pushFile(client, localFile, rpath, fInfo.Size()); err != nil {
+		server := client.Output.Server
+		event := audit.Start(audit.ActionSftpPut, server)
+		event.Source, event.Target, event.Size = path, server+":"+rpath, fInfo.Size()
+
+		err = r.pushFile(client, localFile, common.LocalHasher(r.Option.HashAlgo(), path), rpath, fInfo.Size())
+		event.End(err)
+
+		if err != nil {
 			return err
 		}
 	}
@@ -132,22 +130,38 @@ func (r *RunSftp) pushPath(client *Connect, target, base, path string) (err erro
 	return nil
 }
 
-// pushFile put file to path.
-func (r *RunSftp) pushFile(c *Connect, localFile io.Reader, path string, size int64) (err error) {
+// pushFile put file to path, continued from the existing file if resume, and verified if verify.
+func (r *RunSftp) pushFile(c *Connect, localFile io.ReadSeeker, srcHash common.HashFunc, path string, size int64) (err error) {
 	dir := filepath.Dir(path)
 	if err := c.Connect.MkdirAll(dir); err != nil {
 		return err
 	}
 
-	remoteFile, err := c.Connect.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	dstHash := common.RemoteHasher(c.SSH, c.Connect, r.Option.HashAlgo(), path)
+
+	var offset int64
+	if stat, err := c.Connect.Stat(path); err == nil && r.Option.Resume {
+		offset = r.Option.ResumeOffset(size, stat.Size(), srcHash, dstHash)
+	}
+
+	remoteFile, err := c.Connect.OpenFile(path, common.OpenFlag(offset))
 	if err != nil {
 		return err
 	}
 
 	defer remoteFile.Close()
 
-	rd := io.TeeReader(common.CreateRateLimit(localFile), remoteFile)
+	if err := common.SeekResume(offset, localFile, remoteFile, nil, path); err != nil {
+		return err
+	}
+
+	rd := io.TeeReader(r.Run.RateLimit().Reader(c.Output.Server, localFile), remoteFile)
+
+	if err := c.Output.ProgressPrinter(size-offset, rd, path); err != nil {
+		return err
+	}
+
+	_ = remoteFile.Close()
 
-	r.ProgressWG.Add(1)
-	return c.Output.ProgressPrinter(size, rd, path)
+	return r.Option.VerifyHash(srcHash, dstHash)
 }
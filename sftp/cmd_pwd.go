@@ -14,45 +14,41 @@ import (
 )
 
 // pwd ...
-func (r *RunSftp) pwd() {
-	exit := make(chan bool)
-
-	for s, c := range r.Client {
-		server, client := s, c
-
-		go func() {
-			defer func() { exit <- true }()
-
-			// get writer
-			client.Output.Create(server)
-			w := client.Output.NewWriter()
-
-			// get current directory
-			pwd, _ := client.Connect.Getwd()
-
-			if len(client.Pwd) != 0 {
-				if filepath.IsAbs(client.Pwd) {
-					pwd = client.Pwd
-				} else {
-					pwd = filepath.Join(pwd, client.Pwd)
-				}
+func (r *RunSftp) pwd() error {
+	return r.parallel(func(server string, client *Connect) error {
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
+
+		// get current directory
+		pwd, err := client.Connect.Getwd()
+
+		if len(client.Pwd) != 0 {
+			if filepath.IsAbs(client.Pwd) {
+				pwd, err = client.Pwd, nil
+			} else {
+				pwd = filepath.Join(pwd, client.Pwd)
 			}
+		}
 
-			fmt.Fprintf(w, "%s\n", pwd)
-		}()
-	}
+		if err != nil {
+			return errorf(w, "%s\n", err)
+		}
 
-	for range r.Client {
-		<-exit
-	}
+		fmt.Fprintf(w, "%s\n", pwd)
+
+		return nil
+	})
 }
 
 // lpwd ...
-func (r *RunSftp) lpwd() {
+func (r *RunSftp) lpwd() error {
 	pwd, err := os.Getwd()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		return errorf(os.Stderr, "%s\n", err)
 	}
 
 	fmt.Println(pwd)
+
+	return nil
 }
@@ -6,13 +6,14 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/misc"
 	"github.com/urfave/cli"
 )
 
-func (r *RunSftp) rename(args []string) {
+func (r *RunSftp) rename(args []string) error {
 	// create app
 	app := cli.NewApp()
 	app.CustomAppHelpTemplate = helptext
@@ -27,45 +28,32 @@ func (r *RunSftp) rename(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) renameAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("rename [old] [new]")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		server, client := s, cl
-
+	return r.parallel(func(server string, client *Connect) error {
 		oldname := c.Args()[0]
 		newname := c.Args()[1]
 
-		go func() {
-			defer func() { exit <- true }()
-
-			// get writer
-			client.Output.Create(server)
-			w := client.Output.NewWriter()
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
 
-			// get current directory
-			if err := client.Connect.Rename(oldname, newname); err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return
-			}
+		// get current directory
+		if err := client.Connect.Rename(oldname, newname); err != nil {
+			return errorf(w, "%s\n", err)
+		}
 
-			fmt.Fprintf(w, "rename: %s => %s\n", oldname, newname)
-		}()
-	}
-
-	for range r.Client {
-		<-exit
-	}
+		fmt.Fprintf(w, "rename: %s => %s\n", oldname, newname)
 
-	return nil
+		return nil
+	})
 }
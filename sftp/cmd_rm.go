@@ -6,13 +6,14 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/bingoohuang/bssh/common"
 	"github.com/urfave/cli"
 )
 
-func (r *RunSftp) rm(args []string) {
+func (r *RunSftp) rm(args []string) error {
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
 
@@ -35,33 +36,23 @@ func (r *RunSftp) rm(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) rmAction(c *cli.Context) error {
 	if len(c.Args()) != 1 {
-		fmt.Println("Requires one arguments")
+		err := errorf(os.Stdout, "Requires one arguments\n")
 		fmt.Println("rm [path]")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		go r.doRM(exit, s, cl, c.Args()[0], c)
-	}
-
-	for range r.Client {
-		<-exit
-	}
-
-	return nil
+	return r.parallel(func(server string, client *Connect) error {
+		return r.doRM(server, client, c.Args()[0], c)
+	})
 }
 
-func (r *RunSftp) doRM(exit chan bool, server string, client *Connect, path string, c *cli.Context) {
-	defer func() { exit <- true }()
-
+func (r *RunSftp) doRM(server string, client *Connect, path string, c *cli.Context) error {
 	// get writer
 	client.Output.Create(server)
 	w := client.Output.NewWriter()
@@ -81,8 +72,7 @@ func (r *RunSftp) doRM(exit chan bool, server string, client *Connect, path stri
 		for walker.Step() {
 			err := walker.Err()
 			if err != nil {
-				fmt.Fprintf(w, "Error: %s\n", err)
-				return
+				return errorf(w, "Error: %s\n", err)
 			}
 
 			p := walker.Path()
@@ -97,17 +87,17 @@ func (r *RunSftp) doRM(exit chan bool, server string, client *Connect, path stri
 		for _, p := range data {
 			err := client.Connect.Remove(p)
 			if err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return
+				return errorf(w, "%s\n", err)
 			}
 		}
 	} else {
 		err := client.Connect.Remove(path)
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", err)
-			return
+			return errorf(w, "%s\n", err)
 		}
 	}
 
 	fmt.Fprintf(w, "remove: %s\n", path)
+
+	return nil
 }
@@ -6,13 +6,14 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/misc"
 	"github.com/urfave/cli"
 )
 
-func (r *RunSftp) rmdir(args []string) {
+func (r *RunSftp) rmdir(args []string) error {
 	// create app
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
@@ -28,10 +29,10 @@ func (r *RunSftp) rmdir(args []string) {
 	// action
 	app.Action = func(c *cli.Context) error {
 		if len(c.Args()) != 1 {
-			fmt.Println("Requires one arguments")
+			err := errorf(os.Stdout, "Requires one arguments\n")
 			fmt.Println("rmdir [path]")
 
-			return nil
+			return err
 		}
 
 		for server, client := range r.Client {
@@ -42,8 +43,7 @@ func (r *RunSftp) rmdir(args []string) {
 			// remove directory
 			err := client.Connect.RemoveDirectory(c.Args()[0])
 			if err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return nil
+				return errorf(w, "%s\n", err)
 			}
 
 			fmt.Fprintf(w, "remove dir: %s\n", c.Args()[0])
@@ -55,5 +55,5 @@ func (r *RunSftp) rmdir(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
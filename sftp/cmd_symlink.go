@@ -6,6 +6,7 @@ package sftp
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/bingoohuang/bssh/common"
@@ -14,7 +15,7 @@ import (
 )
 
 // TDXX(blacknon): 転送時の進捗状況を表示するプログレスバーの表示はさせること.
-func (r *RunSftp) symlink(args []string) {
+func (r *RunSftp) symlink(args []string) error {
 	app := cli.NewApp()
 	// app.UseShortOptionHandling = true
 
@@ -30,51 +31,38 @@ func (r *RunSftp) symlink(args []string) {
 	// parse short options
 	args = common.ParseArgs(app.Flags, args)
 
-	_ = app.Run(args)
+	return app.Run(args)
 }
 
 func (r *RunSftp) symlinkAction(c *cli.Context) error {
 	if len(c.Args()) != 2 {
-		fmt.Println("Requires two arguments")
+		err := errorf(os.Stdout, "Requires two arguments\n")
 		fmt.Println("symlink source target")
 
-		return nil
+		return err
 	}
 
-	exit := make(chan bool)
-
-	for s, cl := range r.Client {
-		server, client := s, cl
-
+	return r.parallel(func(server string, client *Connect) error {
 		source := c.Args()[0]
 		target := c.Args()[1]
 
-		go func() {
-			defer func() { exit <- true }()
-
-			// get writer
-			client.Output.Create(server)
-			w := client.Output.NewWriter()
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
 
-			// set arg path
-			if !filepath.IsAbs(source) {
-				source = filepath.Join(client.Pwd, source)
-			}
+		// set arg path
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(client.Pwd, source)
+		}
 
-			if !filepath.IsAbs(target) {
-				target = filepath.Join(client.Pwd, target)
-			}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(client.Pwd, target)
+		}
 
-			if err := client.Connect.Symlink(source, target); err != nil {
-				fmt.Fprintf(w, "%s\n", err)
-				return
-			}
-		}()
-	}
-
-	for range r.Client {
-		<-exit
-	}
+		if err := client.Connect.Symlink(source, target); err != nil {
+			return errorf(w, "%s\n", err)
+		}
 
-	return nil
+		return nil
+	})
 }
@@ -0,0 +1,130 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/urfave/cli"
+)
+
+// tree prints the remote directory tree.
+func (r *RunSftp) tree(args []string) error {
+	app := cli.NewApp()
+	app.CustomAppHelpTemplate = helptext
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{Name: "a", Usage: "do not ignore entries starting with ."},
+		cli.BoolFlag{Name: "d", Usage: "list directories only"},
+		cli.IntFlag{Name: "L", Usage: "descend only `level` directories deep"},
+	}
+	app.Name = "tree"
+	app.Usage = "bssh ftp build-in command: tree [remote machine tree]"
+	app.ArgsUsage = misc.Path
+	app.HideHelp = true
+	app.HideVersion = true
+	app.EnableBashCompletion = true
+	app.Action = r.treeAction
+
+	// parse short options
+	args = common.ParseArgs(app.Flags, args)
+
+	return app.Run(args)
+}
+
+func (r *RunSftp) treeAction(c *cli.Context) error {
+	argpath := c.Args().First()
+
+	return r.parallel(func(server string, client *Connect) error {
+		// get writer
+		client.Output.Create(server)
+		w := client.Output.NewWriter()
+		defer w.Close()
+
+		// set path
+		path := client.Pwd
+		if argpath != "" {
+			path = argpath
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(client.Pwd, path)
+			}
+		}
+
+		t := &treeWalker{client: client, w: w, all: c.Bool("a"), dirOnly: c.Bool("d"), level: c.Int("L")}
+
+		fmt.Fprintf(w, "%s\n", ss.Or(argpath, "."))
+
+		if err := t.walk(path, "", 1); err != nil {
+			return errorf(w, "%s\n", err)
+		}
+
+		if t.dirOnly {
+			fmt.Fprintf(w, "\n%d directories\n", t.dirs)
+		} else {
+			fmt.Fprintf(w, "\n%d directories, %d files\n", t.dirs, t.files)
+		}
+
+		return t.err
+	})
+}
+
+// treeWalker prints the remote directory tree like the tree command.
+type treeWalker struct {
+	client  *Connect
+	w       io.Writer
+	all     bool
+	dirOnly bool
+	level   int
+
+	dirs, files int
+	// err is the first error of the subdirectories, which are printed in the tree
+	err error
+}
+
+func (t *treeWalker) walk(dir, indent string, depth int) error {
+	list, err := t.client.Connect.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(ByName{list})
+
+	entries := list[:0]
+	for _, f := range list {
+		if (t.all || !strings.HasPrefix(f.Name(), ".")) && (!t.dirOnly || f.IsDir()) {
+			entries = append(entries, f)
+		}
+	}
+
+	for i, f := range entries {
+		branch, next := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		fmt.Fprintf(t.w, "%s%s%s\n", indent, branch, f.Name())
+
+		if !f.IsDir() {
+			t.files++
+			continue
+		}
+
+		t.dirs++
+
+		if t.level <= 0 || depth < t.level {
+			if err := t.walk(filepath.Join(dir, f.Name()), indent+next, depth+1); err != nil {
+				fmt.Fprintf(t.w, "%s%s[%s]\n", indent, next, err)
+
+				if t.err == nil {
+					t.err = err
+				}
+			}
+		}
+	}
+
+	return nil
+}
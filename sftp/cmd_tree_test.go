@@ -0,0 +1,40 @@
+package sftp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeWalker(t *testing.T) {
+	c := newMemConnect(t)
+	for _, dir := range []string{"/app", "/app/bin", "/app/conf"} {
+		assert.Nil(t, c.Connect.Mkdir(dir))
+	}
+
+	for _, f := range []string{"/app/.env", "/app/README", "/app/bin/run", "/app/conf/a.toml"} {
+		writeMemFile(t, c, f, f)
+	}
+
+	var b strings.Builder
+	w := &treeWalker{client: c, w: &b}
+	assert.Nil(t, w.walk("/app", "", 1))
+	assert.Equal(t, strings.Join([]string{
+		"├── README",
+		"├── bin",
+		"│   └── run",
+		"└── conf",
+		"    └── a.toml",
+		"",
+	}, "\n"), b.String())
+	assert.Equal(t, 2, w.dirs)
+	assert.Equal(t, 3, w.files)
+
+	b.Reset()
+	w = &treeWalker{client: c, w: &b, all: true, dirOnly: true, level: 1}
+	assert.Nil(t, w.walk("/app", "", 1))
+	assert.Equal(t, "├── bin\n└── conf\n", b.String())
+
+	assert.NotNil(t, (&treeWalker{client: c, w: &b}).walk("/none", "", 1))
+}
@@ -5,19 +5,23 @@
 package sftp
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 
+	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/output"
 	sshl "github.com/bingoohuang/bssh/ssh"
 	"github.com/c-bata/go-prompt"
 	"github.com/pkg/sftp"
 	"github.com/urfave/cli"
-	"github.com/vbauerster/mpb"
+	"golang.org/x/crypto/ssh"
 )
 
 // RunSftp ...
@@ -38,8 +42,13 @@ type RunSftp struct {
 	Permission bool
 
 	// progress bar
-	Progress   *mpb.Progress
-	ProgressWG *sync.WaitGroup
+	Progress *output.Progress
+
+	// resume and verify options of the running get or put
+	Option common.TransferOption
+
+	// include and exclude filter of the running get or put
+	Filter *common.Filter
 
 	// PathComplete
 	RemoteComplete []prompt.Suggest
@@ -51,6 +60,9 @@ type Connect struct {
 	// ssh connect
 	Connect *sftp.Client
 
+	// ssh client, to run the hash commands
+	SSH *ssh.Client
+
 	// Output
 	Output *output.Output
 
@@ -68,8 +80,45 @@ const (
 	oprompt = "${SERVER} :: "
 )
 
+// errorf prints the error of the running command to w, and returns it as the result of the command.
+func errorf(w io.Writer, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	fmt.Fprint(w, msg)
+
+	return errors.New(strings.TrimSpace(msg))
+}
+
+// parallel runs fn on each server concurrently, and returns the first error.
+func (r *RunSftp) parallel(fn func(server string, client *Connect) error) error {
+	exit := make(chan error)
+
+	for s, cl := range r.Client {
+		server, client := s, cl
+
+		go func() { exit <- fn(server, client) }()
+	}
+
+	var first error
+
+	for range r.Client {
+		if err := <-exit; err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
 // Start starts the sftp app.
 func (r *RunSftp) Start(confpath string) {
+	r.connect(confpath)
+
+	// Start sftp shell
+	r.shell()
+}
+
+// connect connects the selected servers.
+func (r *RunSftp) connect(confpath string) {
 	// Create AuthMap
 	r.Run = sshl.NewRun(confpath)
 	r.Run.ServerList = r.SelectServer
@@ -78,9 +127,6 @@ func (r *RunSftp) Start(confpath string) {
 
 	// Create Sftp Connect
 	r.Client = r.createSftpConnect(r.Run.ServerList)
-
-	// Start sftp shell
-	r.shell()
 }
 
 // createSftpConnect ...
@@ -122,6 +168,7 @@ func (r *RunSftp) createSftpConnect(targets []string) (result map[string]*Connec
 			// create SftpConnect
 			sftpCon := &Connect{
 				Connect: ftp,
+				SSH:     conn.Client,
 				Output:  o,
 				Pwd:     "./",
 			}
@@ -143,11 +190,9 @@ func (r *RunSftp) createSftpConnect(targets []string) (result map[string]*Connec
 	return result
 }
 
-func (r *RunSftp) doLs(lsdata map[string]sftpLs, c *cli.Context, exit chan bool, m sync.Locker,
+func (r *RunSftp) doLs(lsdata map[string]sftpLs, c *cli.Context, m sync.Locker,
 	client *Connect, server, argpath string,
-) {
-	defer func() { exit <- true }()
-
+) error {
 	// get output
 	client.Output.Create(server)
 	w := client.Output.NewWriter()
@@ -166,8 +211,7 @@ func (r *RunSftp) doLs(lsdata map[string]sftpLs, c *cli.Context, exit chan bool,
 	// get ls data
 	data, err := r.getRemoteLsData(client, path)
 	if err != nil {
-		fmt.Fprintf(w, "getRemoteLsData Error: %v\n", err)
-		return
+		return errorf(w, "getRemoteLsData Error: %v\n", err)
 	}
 
 	// if `a` flag disable, delete Hidden files...
@@ -194,4 +238,6 @@ func (r *RunSftp) doLs(lsdata map[string]sftpLs, c *cli.Context, exit chan bool,
 	m.Lock()
 	lsdata[server] = data
 	m.Unlock()
+
+	return nil
 }
@@ -19,8 +19,6 @@ import (
 	"github.com/mattn/go-shellwords"
 )
 
-// TDXX(blacknon): catコマンド相当の機能を追加する
-
 // shell Shell mode function.
 func (r *RunSftp) shell() {
 	// start message
@@ -47,61 +45,87 @@ func (r *RunSftp) shell() {
 
 // Executor sftp Shell mode function.
 func (r *RunSftp) Executor(command string) {
+	_ = r.run(command)
+}
+
+// run runs the command, the error is printed already.
+func (r *RunSftp) run(command string) error {
+	// ! or !command...
+	if c := strings.TrimSpace(command); strings.HasPrefix(c, "!") {
+		return r.localShell(c[1:])
+	}
+
 	p := shellwords.NewParser()
 	p.ParseEnv = true
-	cmdline, _ := p.Parse(command)
+	cmdline, err := p.Parse(command)
+	if err != nil {
+		return errorf(os.Stdout, "%s\n", err)
+	}
+
+	if len(cmdline) == 0 { // none command...
+		return nil
+	}
 
 	// switch command
 	switch cmdline[0] {
 	case "bye", "exit", "quit":
 		os.Exit(0)
 	case "help", "?":
-	// case "cat":
+		r.help()
+	case "cat":
+		return r.cat(cmdline)
 	case "cd": // change remote directory
-		r.cd(cmdline)
+		return r.cd(cmdline)
 	case misc.Chgrp:
-		r.chgrp(cmdline)
+		return r.chgrp(cmdline)
 	case "chmod":
-		r.chmod(cmdline)
+		return r.chmod(cmdline)
 	case misc.Chown:
-		r.chown(cmdline)
-	// case "copy":
+		return r.chown(cmdline)
+	case "copy":
+		return r.copy(cmdline)
 	case "df":
-		r.df(cmdline)
+		return r.df(cmdline)
+	case "edit":
+		return r.edit(cmdline)
 	case misc.Get:
-		r.get(cmdline)
+		return r.get(cmdline)
 	case "lcd":
-		r.lcd(cmdline)
+		return r.lcd(cmdline)
 	case misc.Lls:
-		r.lls(cmdline)
+		return r.lls(cmdline)
 	case misc.Lmkdir:
-		r.lmkdir(cmdline)
-	// case "ln":
+		return r.lmkdir(cmdline)
+	case "ln":
+		return r.ln(cmdline)
 	case "lpwd":
-		r.lpwd()
+		return r.lpwd()
 	case "ls":
-		r.ls(cmdline)
-	// case "lumask":
+		return r.ls(cmdline)
+	case "lumask":
+		return r.lumask(cmdline)
 	case misc.Mkdir:
-		r.mkdir(cmdline)
+		return r.mkdir(cmdline)
 	case misc.Put:
-		r.put(cmdline)
+		return r.put(cmdline)
 	case "pwd":
-		r.pwd()
+		return r.pwd()
 	case misc.Rename:
-		r.rename(cmdline)
+		return r.rename(cmdline)
 	case "rm":
-		r.rm(cmdline)
+		return r.rm(cmdline)
 	case misc.Rmdir:
-		r.rmdir(cmdline)
+		return r.rmdir(cmdline)
 	case misc.Symlink:
-		r.symlink(cmdline)
-	// case "tree":
-	// case "!": // ! or !command...
+		return r.symlink(cmdline)
+	case "tree":
+		return r.tree(cmdline)
 	case "": // none command...
 	default:
-		fmt.Println("Command Not Found...")
+		return errorf(os.Stdout, "Command Not Found...\n")
 	}
+
+	return nil
 }
 
 // Completer sftp Shell mode function.
@@ -117,11 +141,21 @@ func (r *RunSftp) Completer(t prompt.Document) []prompt.Suggest {
 
 	// command pattern
 	switch cmdline[0] {
+	case "cat":
+		return r.PathComplete(true, strings.Count(t.CurrentLineBeforeCursor(), " "), t)
 	case "cd":
 		return r.PathComplete(true, 1, t)
+	case "copy":
+		return r.PathComplete(true, strings.Count(t.CurrentLineBeforeCursor(), " "), t)
 	case "df":
 		return r.cmdDf(t)
+	case "edit":
+		return r.PathComplete(true, 1, t)
 	case misc.Get:
+		if char == "-" {
+			return prompt.FilterHasPrefix(transferSuggests, t.GetWordBeforeCursor(), false)
+		}
+
 		switch strings.Count(t.CurrentLineBeforeCursor(), " ") {
 		case 1:
 			return r.PathComplete(true, 1, t) // remote
@@ -134,14 +168,19 @@ func (r *RunSftp) Completer(t prompt.Document) []prompt.Suggest {
 		return r.cmdLls(char, t)
 	case misc.Lmkdir:
 		return r.cmdLmkdir(char, t)
+	case "ln":
+		return r.PathComplete(true, strings.Count(t.CurrentLineBeforeCursor(), " "), t)
 	case "ls":
 		return r.cmdLs(char, t)
-	// case "lumask":
 	case misc.Mkdir:
 		return r.cmdMkdir(char, t)
 	case misc.Put:
 		// TDXX(blacknon): オプションを追加したら引数の数から減らす処理が必要
 		// TDXX（blacknon）：添加选项后，有必要减少参数数量
+		if char == "-" {
+			return prompt.FilterHasPrefix(transferSuggests, t.GetWordBeforeCursor(), false)
+		}
+
 		switch strings.Count(t.CurrentLineBeforeCursor(), " ") {
 		case 1:
 			return r.PathComplete(false, 1, t) // local
@@ -154,6 +193,8 @@ func (r *RunSftp) Completer(t prompt.Document) []prompt.Suggest {
 		return r.PathComplete(true, 1, t)
 	case misc.Rmdir:
 		return r.PathComplete(true, 1, t)
+	case "tree":
+		return r.cmdTree(char, t)
 	default:
 	}
 
@@ -250,6 +291,22 @@ func (r *RunSftp) cmdLls(char string, t prompt.Document) []prompt.Suggest {
 	return r.PathComplete(false, 1, t)
 }
 
+func (r *RunSftp) cmdTree(char string, t prompt.Document) []prompt.Suggest {
+	switch {
+	case ss.AnyOf(char, "-"):
+		suggest := []prompt.Suggest{
+			{Text: "-a", Description: "do not ignore entries starting with ."},
+			{Text: "-d", Description: "list directories only"},
+			{Text: "-L", Description: "descend only level directories deep"},
+		}
+
+		return prompt.FilterHasPrefix(suggest, t.GetWordBeforeCursor(), false)
+	default:
+	}
+
+	return r.PathComplete(true, 1, t)
+}
+
 func (r *RunSftp) cmdDf(t prompt.Document) []prompt.Suggest {
 	suggest := []prompt.Suggest{
 		{Text: "-h", Description: "print sizes in powers of 1024 (e.g., 1023M)"},
@@ -260,40 +317,15 @@ func (r *RunSftp) cmdDf(t prompt.Document) []prompt.Suggest {
 }
 
 func (r *RunSftp) createSuggest() []prompt.Suggest {
-	return []prompt.Suggest{
-		{Text: "bye", Description: "Quit lsftp"},
-		// {Text: "cat", Description: "Open file"},
-		{Text: "cd", Description: "Change remote directory to 'path'"},
-		{Text: misc.Chgrp, Description: "Change group of file 'path' to 'grp'"},
-		{Text: misc.Chown, Description: "Change owner of file 'path' to 'own'"},
-		// {Text: "copy", Description: "Copy to file from 'remote' or 'local' to 'remote' or 'local'"},
-		{Text: "df", Description: "Display statistics for current directory or filesystem containing 'path'"},
-		{Text: "exit", Description: "Quit lsftp"},
-		{Text: misc.Get, Description: "Download file"},
-		// {Text: "reget", Description: "Resume download file"},
-		// {Text: "reput", Description: "Resume upload file"},
-		{Text: "help", Description: "Display this help text"},
-		{Text: "lcd", Description: "Change local directory to 'path'"},
-		{Text: misc.Lls, Description: "Display local directory listing"},
-		{Text: misc.Lmkdir, Description: "Create local directory"},
-		// {Text: "ln", Description: "Link remote file (-s for symlink)"},
-		{Text: "lpwd", Description: "Print local working directory"},
-		{Text: "ls", Description: "Display remote directory listing"},
-		// {Text: "lumask", Description: "Set local umask to 'umask'"},
-		{Text: misc.Mkdir, Description: "Create remote directory"},
-		// {Text: "progress", Description: "Toggle display of progress meter"},
-		{Text: misc.Put, Description: "Upload file"},
-		{Text: "pwd", Description: "Display remote working directory"},
-		{Text: "quit", Description: "Quit sftp"},
-		{Text: misc.Rename, Description: "Rename remote file"},
-		{Text: "rm", Description: "Delete remote file"},
-		{Text: misc.Rmdir, Description: "Remove remote directory"},
-		{Text: misc.Symlink, Description: "Create symbolic link"},
-		// {Text: "tree", Description: "Tree view remote directory"},
-		// {Text: "!command", Description: "Execute 'command' in local shell"},
-		{Text: "!", Description: "Escape to local shell"},
-		{Text: "?", Description: "Display this help text"},
+	suggest := make([]prompt.Suggest, 0, len(sftpCommands))
+
+	for _, c := range sftpCommands {
+		if c.Name != "!command" {
+			suggest = append(suggest, prompt.Suggest{Text: c.Name, Description: c.Description})
+		}
 	}
+
+	return suggest
 }
 
 // PathComplete ...
@@ -6,9 +6,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/output"
 	"github.com/bingoohuang/bssh/sshlib"
@@ -30,7 +33,29 @@ func (r *Run) cmd() {
 	}
 
 	connMap := r.createConnMap()
-	writers := r.createWriter(connMap)
+
+	rlog := r.newRunLog("cmd")
+	defer rlog.Close()
+	rlog.Printf("%s $ %s\n", time.Now().Format("2006/01/02 15:04:05"), command)
+
+	writers, outputs := r.createWriter(connMap, rlog)
+
+	event := audit.Start(audit.ActionCmd, r.ServerList...)
+	event.Command, event.RunID = command, r.RunID()
+
+	var mu sync.Mutex
+	var lastErr error
+	onDone := func(server string, err error) {
+		if err == nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		event.Failed = append(event.Failed, server)
+		lastErr = err
+	}
 
 	// if parallel flag true, and select server is not single,
 	// set send stdin.
@@ -50,8 +75,8 @@ func (r *Run) cmd() {
 	}
 
 	// run command
-	for _, c := range connMap {
-		r.runCommand(c, finished, command, stdinData)
+	for s, c := range connMap {
+		r.runCommand(c, finished, command, stdinData, func(err error) { onDone(s, err) })
 	}
 
 	// wait
@@ -61,12 +86,29 @@ func (r *Run) cmd() {
 
 	close(exitInput)
 
+	if outputs != nil {
+		results := map[string]string{}
+		for s, b := range outputs {
+			results[s] = b.String()
+		}
+
+		output.PrintCollapsed(os.Stdout, r.ServerList, results)
+	}
+
+	sort.Strings(event.Failed)
+	event.End(lastErr)
+
 	time.Sleep(300 * time.Millisecond)
 }
 
-func (r *Run) createWriter(connMap map[string]*sshlib.Connect) []io.WriteCloser {
-	// Run command and print loop
-	var writers []io.WriteCloser
+// createWriter creates the output writers of the connects, and the stdin writers if parallel.
+// The outputs are buffered per host if collapse, to be printed after the command exits.
+func (r *Run) createWriter(connMap map[string]*sshlib.Connect, runLog *runLog) (
+	writers []io.WriteCloser, outputs map[string]*lockedBuffer,
+) {
+	if r.Collapse {
+		outputs = map[string]*lockedBuffer{}
+	}
 
 	for s, c := range connMap {
 		c.Session, _ = c.CreateSession()
@@ -80,7 +122,12 @@ func (r *Run) createWriter(connMap map[string]*sshlib.Connect) []io.WriteCloser
 		}
 		o.Create(s)
 
-		c.Stdout, c.Stderr = o.NewWriter(), o.NewWriter()
+		if outputs != nil {
+			outputs[s] = new(lockedBuffer)
+			c.Stdout, c.Stderr = outputs[s], outputs[s]
+		} else {
+			c.Stdout, c.Stderr = o.NewWriter(), o.NewWriter()
+		}
 
 		// if single server, setup port forwarding.
 		if len(r.ServerList) == 1 {
@@ -89,9 +136,34 @@ func (r *Run) createWriter(connMap map[string]*sshlib.Connect) []io.WriteCloser
 			w, _ := c.Session.StdinPipe()
 			writers = append(writers, w)
 		}
+
+		if runLog != nil {
+			c.Stdout = io.MultiWriter(c.Stdout, runLog.Writer(s))
+			c.Stderr = io.MultiWriter(c.Stderr, runLog.Writer(s))
+		}
 	}
 
-	return writers
+	return writers, outputs
+}
+
+// lockedBuffer is a bytes.Buffer shared by the stdout and stderr of a session.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
 }
 
 func (r *Run) createConnMap() map[string]*sshlib.Connect {
@@ -121,12 +193,12 @@ func (r *Run) createConnMap() map[string]*sshlib.Connect {
 	return connMap
 }
 
-func (r *Run) runCommand(conn *sshlib.Connect, finished chan bool, command string, stdinData []byte) {
+func (r *Run) runCommand(conn *sshlib.Connect, finished chan bool, command string, stdinData []byte, done func(error)) {
 	if r.IsParallel {
 		go func() {
 			defer func() { finished <- true }()
 
-			_ = conn.Command(command)
+			done(conn.Command(command))
 		}()
 
 		return
@@ -141,7 +213,7 @@ func (r *Run) runCommand(conn *sshlib.Connect, finished chan bool, command strin
 		go func() {
 			defer func() { finished <- true }()
 
-			_ = conn.Command(command)
+			done(conn.Command(command))
 		}()
 
 		// send stdin
@@ -149,7 +221,7 @@ func (r *Run) runCommand(conn *sshlib.Connect, finished chan bool, command strin
 		_ = w.Close()
 	} else {
 		// run command
-		_ = conn.Command(command)
+		done(conn.Command(command))
 		go func() { finished <- true }()
 	}
 }
@@ -170,16 +242,22 @@ func (r *Run) setupPortForwarding(config *conf.ServerConfig, c *sshlib.Connect)
 	r.printPortForward(config.PortForwardMode, config.PortForwardLocal, config.PortForwardRemote)
 
 	// Port Forwarding
-	switch config.PortForwardMode {
-	case "L", "":
-		_ = c.TCPLocalForward(config.PortForwardLocal, config.PortForwardRemote)
-	case "R":
-		_ = c.TCPRemoteForward(config.PortForwardLocal, config.PortForwardRemote)
+	if config.PortForwardLocal != "" && config.PortForwardRemote != "" {
+		var err error
+		switch config.PortForwardMode {
+		case "L", "":
+			err = c.TCPLocalForward(config.PortForwardLocal, config.PortForwardRemote)
+		case "R":
+			err = c.TCPRemoteForward(config.PortForwardLocal, config.PortForwardRemote)
+		}
+
+		auditForward(config.ID, config.PortForwardMode, config.PortForwardLocal, config.PortForwardRemote, err)
 	}
 
 	// Dynamic Port Forwarding
 	if config.DynamicPortForward != "" {
 		r.printDynamicPortForward(config.DynamicPortForward)
+		auditForward(config.ID, "D", config.DynamicPortForward, "", nil)
 
 		go func() { _ = c.TCPDynamicForward("localhost", config.DynamicPortForward) }()
 	}
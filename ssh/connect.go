@@ -10,6 +10,7 @@ import (
 
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/bssh/output"
 	"github.com/bingoohuang/bssh/sshlib"
 	"github.com/bingoohuang/ngg/gnet"
 	"golang.org/x/net/proxy"
@@ -80,9 +81,11 @@ func (r *Run) CreateSSHConnect(serverConfig *conf.ServerConfig, server string) (
 
 	// connect target server
 	connect = &sshlib.Connect{
-		ProxyDialer: dialer, ForwardAgent: serverConfig.SSHAgentUse,
+		ServerID: server, ProxyDialer: dialer, ForwardAgent: serverConfig.SSHAgentUse,
 		Agent: r.agent, ForwardX11: x11, TTY: r.IsTerm, ConnectTimeout: serverConfig.ConnectTimeout,
 		SendKeepAliveMax: serverConfig.ServerAliveCountMax, SendKeepAliveInterval: serverConfig.ServerAliveCountInterval,
+		RateLimit: r.RateLimit().ReaderFunc(server), LimitForward: r.Conf.Transfer.Forward,
+		NewBar: func(size int64) sshlib.ProgressBar { return output.NewTermBar(size, os.Stdout) },
 	}
 
 	addr, port := resolveIP2Override(serverConfig.Addr, serverConfig.Port)
@@ -96,21 +99,30 @@ func (r *Run) CreateSSHConnect(serverConfig *conf.ServerConfig, server string) (
 }
 
 func proxyByEnv(serverConfig *conf.ServerConfig, forwarder proxy.Dialer) (proxy.Dialer, error) {
-	// 按优先级顺序检查代理环境变量
-	name, env := "PROXY", sshlib.Getenv("PROXY")
+	name, env := lookupProxyEnv()
 	if env == "" {
-		name, env = "https_proxy", sshlib.Getenv("https_proxy")
+		return nil, nil
 	}
+
+	log.Printf("proxy by $%s = %s", name, env)
+
+	return createEnvProxyDialer(env, serverConfig, forwarder)
+}
+
+// lookupProxyEnv 按优先级顺序检查代理环境变量
+func lookupProxyEnv() (name, env string) {
+	name, env = "PROXY", sshlib.Getenv("PROXY")
 	if env == "" {
-		name, env = "http_proxy", sshlib.Getenv("http_proxy")
+		name, env = "https_proxy", sshlib.Getenv("https_proxy")
 	}
-
 	if env == "" {
-		return nil, nil
+		name, env = "http_proxy", sshlib.Getenv("http_proxy")
 	}
 
-	log.Printf("proxy by $%s = %s", name, env)
+	return name, env
+}
 
+func createEnvProxyDialer(env string, serverConfig *conf.ServerConfig, forwarder proxy.Dialer) (proxy.Dialer, error) {
 	if strings.HasPrefix(env, "command://") {
 		val := strings.TrimPrefix(env, "command://")
 		val = expandProxyCommand(val, *serverConfig)
@@ -225,6 +237,11 @@ func expandProxyCommand(proxyCommand string, config conf.ServerConfig) string {
 	return proxyCommand
 }
 
+// ServerAddr returns the address and port to connect the server, overridden by the IP2 env.
+func ServerAddr(c conf.ServerConfig) (addr, port string) {
+	return resolveIP2Override(c.Addr, c.Port)
+}
+
 // resolveIP2Override 处理 IP2 环境变量来覆盖配置中的地址和端口。
 // 应对场景：金良小主机 IP 经常发生变化，可以通过 IP2 环境变量来重置配置中的 IP。
 // 支持以下格式：
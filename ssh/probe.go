@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/bingoohuang/bssh/misc"
+	"github.com/bingoohuang/bssh/sshlib"
+	"github.com/bingoohuang/ngg/gnet"
+	"github.com/bingoohuang/ngg/ss"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// ViaError is returned by Probe for the server behind a ssh proxy,
+// whose reachability is unknown, because the probe uses no credentials to login the proxy.
+type ViaError struct {
+	// Proxy is the first ssh proxy of the route.
+	Proxy string
+}
+
+func (e *ViaError) Error() string { return "unknown, via " + e.Proxy }
+
+// Probe checks the reachability of server through its proxy route, and returns the latency.
+// It dials tcp to the server, or does a ssh handshake without authentication if handshake is true.
+// The server behind a ssh proxy is not probed, a *ViaError is returned instead.
+func Probe(server string, config conf.Config, handshake bool, timeout time.Duration) (time.Duration, error) {
+	c, ok := config.Server[server]
+	if !ok {
+		return 0, errors.New("server not found")
+	}
+
+	proxyRoute, err := getProxyRoute(server, config)
+	if err != nil {
+		return 0, err
+	}
+
+	var dialer proxy.Dialer = gnet.DialerTimeoutBean{ConnTimeout: timeout}
+
+	for _, p := range proxyRoute {
+		switch p.Type {
+		case misc.HTTP, misc.HTTPS, misc.Socks, misc.Socks5:
+			pc := config.Proxy[p.Name]
+			pxy := &sshlib.Proxy{Type: p.Type, Forwarder: dialer, Addr: pc.Addr, Port: pc.Port, User: pc.User, Password: pc.Pass}
+			dialer, err = pxy.CreateProxyDialer()
+		case misc.Command:
+			dialer, err = (&sshlib.Proxy{Type: p.Type, Command: p.Name}).CreateProxyDialer()
+		default:
+			if _, ok := config.Server[p.Name]; !ok {
+				return 0, errors.New("proxy not found: " + p.Name)
+			}
+
+			return 0, &ViaError{Proxy: p.Name}
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(proxyRoute) == 0 {
+		if _, env := lookupProxyEnv(); env != "" {
+			if dialer, err = createEnvProxyDialer(env, &c, dialer); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	targetInfo, uri := sshlib.CreateTargetInfo(net.JoinHostPort(c.Addr, ss.Or(c.Port, "22")), c.Brg)
+
+	start := time.Now()
+	conn, err := dialer.Dial("tcp", uri)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if !handshake {
+		return time.Since(start), nil
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	for _, target := range targetInfo {
+		if _, err := conn.Write([]byte(target)); err != nil {
+			return 0, err
+		}
+	}
+
+	sc := &ssh.ClientConfig{User: c.User, HostKeyCallback: ssh.InsecureIgnoreHostKey(), Timeout: timeout}
+	sshConn, _, _, err := ssh.NewClientConn(conn, uri, sc)
+	// the handshake is done when the server refuses the authentication.
+	if err != nil && !strings.Contains(err.Error(), "unable to authenticate") {
+		return 0, err
+	}
+
+	if sshConn != nil {
+		sshConn.Close()
+	}
+
+	return time.Since(start), nil
+}
@@ -7,13 +7,17 @@ import (
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/output"
 	"github.com/bingoohuang/bssh/sshlib"
 	"github.com/c-bata/go-prompt"
 	"github.com/c-bata/go-prompt/completer"
+	"github.com/pkg/sftp"
 )
 
 // TDXX(blacknon): 接続が切れた場合の再接続処理、および再接続ができなかった場合のsliceからの削除対応の追加(v0.6.1)
@@ -27,9 +31,19 @@ type pShell struct {
 	Count         int
 	ServerList    []string
 	Connects      []*psConnect
+	sshRun        *Run
+	config        conf.ShellConfig
+	target        map[string]bool
+	hostsMu       sync.Mutex
 	PROMPT        string
 	History       map[int]map[string]*pShellHistory
 	HistoryFile   string
+	redactor      *sshlib.Redactor
+	runLog        *runLog
+	runID         string
+	auditEvent    *audit.Event
+	auditErr      error
+	auditMu       sync.Mutex
 	latestCommand string
 	CmdComplete   []prompt.Suggest
 	PathComplete  []prompt.Suggest
@@ -41,6 +55,21 @@ type pShellOption struct {
 	// local command実行時の結果をHistoryResultに記録しない(os.Stdoutに直接出す)
 	LocalCommandNotRecordResult bool
 
+	// Header prints the output prompt before the lines of hosts.
+	Header bool
+
+	// Collapse prints the identical outputs of hosts once, after the command exits.
+	Collapse bool
+
+	// Timeout of each command, 0 is unlimited.
+	Timeout time.Duration
+
+	// TrackCd runs the following commands in the directory of `cd dir`.
+	TrackCd bool
+
+	// Color mode of the server names, auto, always or never.
+	Color string
+
 	// trueの場合、リモートマシンでパイプライン処理をする際にパイプ経由でもOPROMPTを付与して出力する
 	// RemoteHeaderWithPipe bool
 
@@ -59,6 +88,18 @@ type psConnect struct {
 	Name   string
 	Output *output.Output
 	*sshlib.Connect
+
+	// Inactive is true if dropped from the active hosts by %drop or %only.
+	Inactive bool
+	// Down is the error of the connection lost or failed.
+	Down error
+	// Cwd is the directory tracked by `cd dir` if the trackcd option is on.
+	Cwd string
+
+	// sftp client of %get and %put.
+	sftp     *sftp.Client
+	sftpErr  error
+	sftpOnce sync.Once
 }
 
 const (
@@ -109,9 +150,23 @@ func (r *Run) pshell() (err error) {
 		Signal:      make(chan os.Signal),
 		ServerList:  r.ServerList,
 		Connects:    cons,
+		sshRun:      r,
+		config:      config,
+		Options:     newPShellOption(config),
 		PROMPT:      config.Prompt,
 		History:     map[int]map[string]*pShellHistory{},
 		HistoryFile: config.HistoryFile,
+		redactor:    r.Conf.Redact.Redactor(),
+		runLog:      r.newRunLog("pshell"),
+		runID:       r.RunID(),
+	}
+
+	if r.Collapse {
+		ps.Options.Collapse = true
+	}
+
+	for _, c := range ps.Connects {
+		ps.Options.applyOutput(c)
 	}
 
 	// set signal
@@ -145,6 +200,7 @@ func (r *Run) pshell() (err error) {
 
 	// start go-prompt
 	p.Run()
+	ps.runLog.Close()
 
 	return nil
 }
@@ -152,32 +208,45 @@ func (r *Run) pshell() (err error) {
 func (r *Run) createPsConnects(config conf.ShellConfig) []*psConnect {
 	// Connect
 	cons := make([]*psConnect, len(r.ServerList))
+	alive := 0
 
 	for i, server := range r.ServerList {
-		con, err := r.CreateSSHConnect(nil, server)
-		if err != nil {
-			log.Println(err)
-			continue
+		cons[i] = r.createPsConnect(config, server, r.ServerList)
+		if cons[i].Down == nil {
+			alive++
 		}
+	}
 
-		// TTY enable
-		con.TTY = true
+	if alive == 0 {
+		return nil
+	}
 
-		// Create Output
-		o := &output.Output{
-			Templete:   config.OPrompt,
-			ServerList: r.ServerList,
-			Conf:       r.Conf.Server[server],
-			AutoColor:  true,
-		}
+	return cons
+}
 
-		// Create output prompt
-		o.Create(server)
+// createPsConnect connects the server, the connect is marked down if failed.
+func (r *Run) createPsConnect(config conf.ShellConfig, server string, serverList []string) *psConnect {
+	// Create Output
+	o := &output.Output{
+		Templete:   config.OPrompt,
+		ServerList: serverList,
+		Conf:       r.Conf.Server[server],
+		AutoColor:  true,
+	}
+
+	// Create output prompt
+	o.Create(server)
 
-		cons[i] = &psConnect{Name: server, Output: o, Connect: con}
+	con, err := r.CreateSSHConnect(nil, server)
+	if err != nil {
+		log.Println(err)
+		return &psConnect{Name: server, Output: o, Down: err}
 	}
 
-	return cons
+	// TTY enable
+	con.TTY = true
+
+	return &psConnect{Name: server, Output: o, Connect: con}
 }
 
 // CreatePrompt is create shell prompt.
@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -22,12 +23,6 @@ import (
 // TDXX(blacknon): 以下のBuild-in Commandを追加する
 //     - %cd <PATH>         ... リモートのディレクトリを変更する(事前のチェックにsftpを使用か？)
 //     - %lcd <PATH>        ... ローカルのディレクトリを変更する
-//     - %save <num> <PATH> ... 指定したnumの履歴をPATHに記録する (v0.6.1)
-//     - %set <args..>      ... 指定されたオプションを設定する(Optionsにて管理) (v0.6.1)
-//     - %diff <num>        ... 指定されたnumの履歴をdiffする(multi diff)。できるかどうか要検討。 (v0.6.1以降)
-//                              できれば、vimdiffのように横に差分表示させるようにしたいものだけど…？
-//     - %get remote local  ... sftpプロトコルを利用して、ファイルやディレクトリを取得する (v0.6.1)
-//     - %put local remote  ... sftpプロトコルを利用して、ファイルやディレクトリを配置する (v0.6.1)
 
 // checkBuildInCommand return true if cmd is build-in command.
 func checkBuildInCommand(cmd string) (isBuildInCmd bool) {
@@ -38,7 +33,8 @@ func checkBuildInCommand(cmd string) (isBuildInCmd bool) {
 
 	case
 		"%history",
-		misc.PercentOut, "%outlist",
+		misc.PercentOut, "%outlist", "%diff", "%get", "%put",
+		"%hosts", "%add", "%drop", "%only",
 		"%save", "%set": // parsent build-in command.
 		isBuildInCmd = true
 	}
@@ -78,6 +74,7 @@ func (ps *pShell) run(pl pipeLine, in io.Reader, out *io.PipeWriter, ch chan<- b
 	switch command {
 	// exit or quit
 	case "exit", "quit":
+		ps.runLog.Close()
 		os.Exit(0)
 
 	// clear
@@ -111,6 +108,58 @@ func (ps *pShell) run(pl pipeLine, in io.Reader, out *io.PipeWriter, ch chan<- b
 		ps.buildinOut(num, out, ch)
 
 		return
+
+	// %diff [-y] [num] [hostA hostB]
+	case "%diff":
+		ps.buildinDiff(pl.Args[1:], out, ch)
+		return
+
+	// %get [-H host1,host2] remote... local
+	case "%get":
+		ps.buildinGet(pl.Args[1:], out, ch)
+		return
+
+	// %put [-H host1,host2] local... remote
+	case "%put":
+		ps.buildinPut(pl.Args[1:], out, ch)
+		return
+
+	// %set [name value]
+	case "%set":
+		ps.buildinSet(pl.Args[1:], out, ch)
+		return
+
+	// %save <num> <path>
+	case "%save":
+		ps.buildinSave(pl.Args[1:], out, ch)
+		return
+
+	// cd <dir>, tracked for the following commands
+	case "cd":
+		if ps.Options.TrackCd && setInput(in) == os.Stdin && out == nil {
+			ps.trackCd(pl.Args[1:], ch)
+			return
+		}
+
+	// %hosts
+	case "%hosts":
+		ps.buildinHosts(out, ch)
+		return
+
+	// %add <server>
+	case "%add":
+		ps.buildinAdd(pl.Args[1:], out, ch)
+		return
+
+	// %drop <server>
+	case "%drop":
+		ps.buildinDrop(pl.Args[1:], out, ch)
+		return
+
+	// %only <expr>
+	case "%only":
+		ps.buildinOnly(pl.Args[1:], out, ch)
+		return
 	}
 
 	// check and exec local command
@@ -228,15 +277,21 @@ func (ps *pShell) executeRemotePipeLine(pline pipeLine, in io.Reader, out *io.Pi
 	exit := make(chan bool)
 	exitInput := make(chan bool) // Input finish channel
 
-	writers := make([]io.WriteCloser, len(ps.Connects))
-	sessions := make([]*ssh.Session, len(ps.Connects))
+	var writers []io.WriteCloser
+	var sessions []*ssh.Session
+	var cons []*psConnect
+
+	// outputs of hosts, printed after the command exits if collapse.
+	collapse := ps.Options.Collapse && stdout == os.Stdout
+	var outputs []*bytes.Buffer
 
 	// create session and writers
 	m := new(sync.Mutex)
 
-	for i, c := range ps.Connects {
+	for _, c := range ps.targetConnects() {
 		s, err := c.CreateSession()
 		if err != nil {
+			ps.markDown(c, err)
 			continue
 		}
 
@@ -250,25 +305,33 @@ func (ps *pShell) executeRemotePipeLine(pline pipeLine, in io.Reader, out *io.Pi
 
 		ow = stdout
 		if ow == os.Stdout {
-			// create Output Writer
-			c.Output.Count = ps.Count
-			w := c.Output.NewWriter()
-
 			// create pShellHistory Writer
 			hw := ps.NewHistoryWriter(c.Output.Server, c.Output, m)
 
-			ow = io.MultiWriter(w, hw)
-			_ = w.CloseWithError(io.ErrClosedPipe)
+			if collapse {
+				buf := new(bytes.Buffer)
+				outputs = append(outputs, buf)
+				ow = io.MultiWriter(buf, hw)
+			} else {
+				// create Output Writer
+				c.Output.Count = ps.Count
+				w := c.Output.NewWriter()
+
+				ow = io.MultiWriter(w, hw)
+				_ = w.CloseWithError(io.ErrClosedPipe)
+			}
+
 			_ = hw.CloseWithError(io.ErrClosedPipe)
 		}
 
-		s.Stdout = ow
+		s.Stdout = io.MultiWriter(ow, ps.runLog.Writer(c.Name))
 
 		// get and append stdin writer
 		w, _ := s.StdinPipe()
 
-		writers[i] = w
-		sessions[i] = s
+		writers = append(writers, w)
+		sessions = append(sessions, s)
+		cons = append(cons, c)
 	}
 
 	// multi input-writer
@@ -284,11 +347,14 @@ func (ps *pShell) executeRemotePipeLine(pline pipeLine, in io.Reader, out *io.Pi
 	}
 
 	// run command
-	for _, s := range sessions {
-		session := s
+	for i, s := range sessions {
+		session, c := s, cons[i]
 
 		go func() {
-			_ = session.Run(command)
+			if err := session.Run(ps.withCwd(c, command)); err != nil {
+				ps.auditFailed(c.Name, err)
+				ps.checkAlive(c, err)
+			}
 			//_ = session.Close()
 			exit <- true
 		}()
@@ -303,7 +369,33 @@ func (ps *pShell) executeRemotePipeLine(pline pipeLine, in io.Reader, out *io.Pi
 		}
 	}()
 
-	wait(len(sessions), exit)
+	// kill the sessions after the timeout
+	if timeout := ps.Options.Timeout; timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			fmt.Fprintf(os.Stderr, "\n---\ncommand timeout after %s\n", timeout)
+
+			for _, s := range sessions {
+				_ = s.Signal(ssh.SIGKILL)
+				_ = s.Close()
+			}
+		})
+
+		wait(len(sessions), exit)
+		timer.Stop()
+	} else {
+		wait(len(sessions), exit)
+	}
+
+	if collapse {
+		var names []string
+		results := map[string]string{}
+		for i, c := range cons {
+			names = append(names, c.Name)
+			results[c.Name] = outputs[i].String()
+		}
+
+		output.PrintCollapsed(os.Stdout, names, results)
+	}
 
 	// wait time (0.500 sec)
 	time.Sleep(5000 * time.Millisecond)
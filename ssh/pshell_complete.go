@@ -62,8 +62,15 @@ func (ps *pShell) Completer(t prompt.Document) []prompt.Suggest {
 				{Text: "%history", Description: "show history"},
 				{Text: misc.PercentOut, Description: "%out [num], show history result."},
 				{Text: "%outlist", Description: "%outlist, show history result list."},
-				// outの出力でdiffをするためのローカルコマンド。すべての出力と比較するのはあまりに辛いと思われるため、最初の出力との比較、といった方式で対応するのが良いか？？
-				// {Text: "%diff", Description: "%diff [num], show history result list."},
+				{Text: "%diff", Description: "%diff [-y] [num] [hostA hostB], diff history result between hosts."},
+				{Text: "%get", Description: "%get [-H host,...] remote... local, get files from hosts by sftp."},
+				{Text: "%put", Description: "%put [-H host,...] local... remote, put files to hosts by sftp."},
+				{Text: "%set", Description: "%set [name value], show or set options: header, collapse, timeout, trackcd, color."},
+				{Text: "%save", Description: "%save <num> <dir>, save history result of hosts to files."},
+				{Text: "%hosts", Description: "%hosts, show hosts and their states."},
+				{Text: "%add", Description: "%add <server>, add server to active hosts, connect if needed."},
+				{Text: "%drop", Description: "%drop <server>, drop server from active hosts."},
+				{Text: "%only", Description: "%only <expr>, set active hosts, like web*,group:db."},
 			}
 
 			// get remote and local command complete data
@@ -101,7 +108,7 @@ func (ps *pShell) Completer(t prompt.Document) []prompt.Suggest {
 func (ps *pShell) buildinSuggests(c string, t prompt.Document) []prompt.Suggest {
 	var a []prompt.Suggest
 
-	if c == misc.PercentOut {
+	if c == misc.PercentOut || c == "%diff" || c == "%save" {
 		for i := 0; i < len(ps.History); i++ {
 			var cmd string
 			for _, h := range ps.History[i] {
@@ -154,12 +161,16 @@ func (ps *pShell) GetCommandComplete() {
 	cmdMap := map[string][]string{}
 
 	// append command to cmdMap
-	for _, c := range ps.Connects {
+	for _, c := range ps.targetConnects() {
 		// Create buffer
 		buf := new(bytes.Buffer)
 
 		// Create session, and output to buffer
-		session, _ := c.CreateSession()
+		session, err := c.CreateSession()
+		if err != nil {
+			ps.markDown(c, err)
+			continue
+		}
 		session.Stdout = buf
 
 		// Run get complete command
@@ -229,8 +240,10 @@ func (ps *pShell) remoteSuggest(command string) (p []prompt.Suggest) {
 	// create sync mutex
 	sm := new(sync.Mutex)
 
+	cons := ps.targetConnects()
+
 	// append path to m
-	for _, c := range ps.Connects {
+	for _, c := range cons {
 		con := c
 
 		go func() {
@@ -240,7 +253,11 @@ func (ps *pShell) remoteSuggest(command string) (p []prompt.Suggest) {
 			buf := new(bytes.Buffer)
 
 			// Create session, and output to buffer
-			session, _ := con.CreateSession()
+			session, err := con.CreateSession()
+			if err != nil {
+				ps.markDown(con, err)
+				return
+			}
 			session.Stdout = buf
 
 			// Run get complete command
@@ -257,7 +274,7 @@ func (ps *pShell) remoteSuggest(command string) (p []prompt.Suggest) {
 		}()
 	}
 
-	for range ps.Connects {
+	for range cons {
 		<-exit
 	}
 
@@ -0,0 +1,243 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bingoohuang/bssh/output"
+	"github.com/mattn/go-runewidth"
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/term"
+)
+
+// buildinDiff diffs the outputs of a command between the hosts.
+// example:
+//   - %diff                  ... the latest command, each variant against the majority
+//   - %diff <num>            ... the command of history num
+//   - %diff <num> web1 web2  ... web1 against web2
+//   - %diff -y ...           ... side by side
+func (ps *pShell) buildinDiff(args []string, out *io.PipeWriter, ch chan<- bool) {
+	stdout := setOutput(out)
+
+	defer func() {
+		// close out
+		if _, ok := stdout.(*io.PipeWriter); ok {
+			_ = out.CloseWithError(io.ErrClosedPipe)
+		}
+
+		// send exit
+		ch <- true
+	}()
+
+	num, sideBySide := ps.Count-1, false
+
+	var hosts []string
+
+	for _, arg := range args {
+		if arg == "-y" {
+			sideBySide = true
+		} else if n, err := strconv.Atoi(arg); err == nil && len(hosts) == 0 {
+			num = n
+		} else {
+			hosts = append(hosts, arg)
+		}
+	}
+
+	if len(hosts) != 0 && len(hosts) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %%diff [-y] [num] [hostA hostB]\n")
+		return
+	}
+
+	histories := ps.History[num]
+	if len(histories) == 0 {
+		fmt.Fprintf(os.Stderr, "no history output of %d\n", num)
+		return
+	}
+
+	results := map[string]string{}
+	for server, h := range histories {
+		results[server] = h.Result
+	}
+
+	for _, h := range histories {
+		fmt.Fprintf(os.Stderr, "[History:%s ]\n", h.Command)
+		break
+	}
+
+	color := stdout == os.Stdout
+	diff := func(nameA, a, nameB, b string) {
+		if sideBySide {
+			writeSideBySide(stdout, nameA, a, nameB, b, sideBySideWidth(), color)
+		} else {
+			writeUnifiedDiff(stdout, nameA, a, nameB, b, color)
+		}
+	}
+
+	if len(hosts) == 2 {
+		for _, host := range hosts {
+			if _, ok := results[host]; !ok {
+				fmt.Fprintf(os.Stderr, "no output of %s in history %d\n", host, num)
+				return
+			}
+		}
+
+		if results[hosts[0]] == results[hosts[1]] {
+			fmt.Fprintf(stdout, "%s and %s have identical output\n", hosts[0], hosts[1])
+			return
+		}
+
+		diff(hosts[0], results[hosts[0]], hosts[1], results[hosts[1]])
+
+		return
+	}
+
+	// the hosts of the history, which may be not in ServerList after %drop or %only.
+	groups := output.GroupOutputs(historyHosts(ps.ServerList, results), results)
+	if len(groups) == 1 {
+		fmt.Fprintf(stdout, "all %d hosts have identical output\n", len(groups[0].Servers))
+		return
+	}
+
+	majority := groups[0]
+	fmt.Fprintf(stdout, "%d variants, majority (%d hosts): %s\n",
+		len(groups), len(majority.Servers), output.CompactHosts(majority.Servers))
+
+	for _, g := range groups[1:] {
+		fmt.Fprintf(stdout, "\n== %d hosts: %s\n", len(g.Servers), output.CompactHosts(g.Servers))
+		diff(majority.Servers[0]+" (majority)", majority.Result, g.Servers[0], g.Result)
+	}
+}
+
+// historyHosts returns the hosts of results, in the order of serverList, and the others sorted at last.
+func historyHosts(serverList []string, results map[string]string) []string {
+	hosts := make([]string, 0, len(results))
+	for _, server := range serverList {
+		if _, ok := results[server]; ok {
+			hosts = append(hosts, server)
+		}
+	}
+
+	var others []string
+	for server := range results {
+		if !slices.Contains(serverList, server) {
+			others = append(others, server)
+		}
+	}
+
+	sort.Strings(others)
+
+	return append(hosts, others...)
+}
+
+// writeUnifiedDiff writes the unified diff of a against b, with +/- lines colored if color.
+func writeUnifiedDiff(w io.Writer, nameA, a, nameB, b string, color bool) {
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A: diffLines(a), B: diffLines(b),
+		FromFile: nameA, ToFile: nameB, Context: 3,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diff error: %v\n", err)
+		return
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case !color, strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "-"):
+			line = "\x1b[31m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		case strings.HasPrefix(line, "+"):
+			line = "\x1b[32m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		case strings.HasPrefix(line, "@@"):
+			line = "\x1b[36m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		}
+
+		_, _ = io.WriteString(w, line)
+	}
+}
+
+// writeSideBySide writes a and b side by side in width columns, like diff -y.
+// The lines are marked with | (changed), < (only in a) or > (only in b).
+func writeSideBySide(w io.Writer, nameA, a, nameB, b string, width int, color bool) {
+	col := (width - 3) / 2
+	al, bl := splitResultLines(a), splitResultLines(b)
+
+	line := func(left, mark, right string) {
+		text := runewidth.FillRight(runewidth.Truncate(left, col, "…"), col) + " " + mark + " " +
+			runewidth.Truncate(right, col, "…")
+		if color && mark != " " {
+			text = "\x1b[33m" + text + "\x1b[0m"
+		}
+
+		fmt.Fprintln(w, strings.TrimRight(text, " "))
+	}
+
+	line(nameA, " ", nameB)
+	line(strings.Repeat("-", col), " ", strings.Repeat("-", col))
+
+	for _, op := range difflib.NewMatcher(al, bl).GetOpCodes() {
+		as, bs := al[op.I1:op.I2], bl[op.J1:op.J2]
+
+		switch op.Tag {
+		case 'e':
+			for i := range as {
+				line(as[i], " ", bs[i])
+			}
+		case 'd':
+			for _, s := range as {
+				line(s, "<", "")
+			}
+		case 'i':
+			for _, s := range bs {
+				line("", ">", s)
+			}
+		case 'r':
+			for i := 0; i < len(as) || i < len(bs); i++ {
+				switch {
+				case i >= len(as):
+					line("", ">", bs[i])
+				case i >= len(bs):
+					line(as[i], "<", "")
+				default:
+					line(as[i], "|", bs[i])
+				}
+			}
+		}
+	}
+}
+
+func splitResultLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// diffLines splits s into the lines ending with \n for the unified diff.
+func diffLines(s string) []string {
+	lines := splitResultLines(s)
+	for i := range lines {
+		lines[i] += "\n"
+	}
+
+	return lines
+}
+
+// sideBySideWidth returns the terminal width, 160 if not a terminal.
+func sideBySideWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 40 {
+		return width
+	}
+
+	return 160
+}
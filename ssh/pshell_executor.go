@@ -7,7 +7,12 @@ package ssh
 import (
 	"fmt"
 	"io"
+	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/audit"
 )
 
 // PipeSet is pipe in/out set struct.
@@ -21,8 +26,18 @@ func (ps *pShell) Executor(command string) {
 	// trim space
 	command = strings.TrimSpace(command)
 
+	// parse the @hosts prefix, the command runs only on the hosts
+	line, target, err := ps.parseTarget(command)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	ps.target = target
+	defer func() { ps.target = nil }()
+
 	// parse command
-	pslice, _ := parsePipeLine(command)
+	pslice, _ := parsePipeLine(line)
 	if len(pslice) == 0 {
 		return
 	}
@@ -32,9 +47,26 @@ func (ps *pShell) Executor(command string) {
 
 	// register history
 	_ = ps.PutHistoryFile(command)
+	ps.runLog.Printf("%s [%d] <<< %s\n", time.Now().Format("2006/01/02 15:04:05"), ps.Count, command)
 
 	// exec pipeline
+	ps.auditEvent = audit.Start(audit.ActionPshell, ps.targetNames()...)
+	ps.auditEvent.Command, ps.auditEvent.RunID = command, ps.runID
+	ps.auditErr = nil
+
 	ps.parseExecutor(pslice)
+
+	sort.Strings(ps.auditEvent.Failed)
+	ps.auditEvent.End(ps.auditErr)
+}
+
+// auditFailed records the server that the command failed on.
+func (ps *pShell) auditFailed(server string, err error) {
+	ps.auditMu.Lock()
+	defer ps.auditMu.Unlock()
+
+	ps.auditEvent.Failed = append(ps.auditEvent.Failed, server)
+	ps.auditErr = err
 }
 
 // parseExecutor assemble and execute the parsed command line.
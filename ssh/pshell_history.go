@@ -104,7 +104,7 @@ func (ps *pShell) GetHistoryFromFile() (data []pShellHistory, err error) {
 	return data, err
 }
 
-// PutHistoryFile put history text to s.HistoryFile
+// PutHistoryFile put history text to s.HistoryFile, the secrets in cmd are masked.
 // ex.) write history(history file format)
 //
 //	YYYY-mm-dd_HH:MM:SS command...
@@ -123,7 +123,7 @@ func (ps *pShell) PutHistoryFile(cmd string) (err error) {
 	// Get Time
 	timestamp := time.Now().Format("2006/01/02_15:04:05 ") // "yyyy/mm/dd_HH:MM:SS "
 
-	fmt.Fprintln(file, timestamp+cmd)
+	fmt.Fprintln(file, timestamp+ps.redactor.Redact(cmd))
 
 	return
 }
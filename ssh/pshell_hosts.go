@@ -0,0 +1,286 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bingoohuang/bssh/conf"
+	"golang.org/x/crypto/ssh"
+)
+
+// groupPrefix is the prefix of the group in the host expressions, like @group:db.
+const groupPrefix = "group:"
+
+// matchHosts returns the names matched by expr, the comma separated server names,
+// glob patterns like web*, or groups like group:db, in the order of names.
+func matchHosts(cf *conf.Config, expr string, names []string) (matched []string) {
+	found := map[string]bool{}
+
+	for _, item := range strings.Split(expr, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if group, ok := strings.CutPrefix(item, groupPrefix); ok {
+			for _, name := range cf.FilterNamesByGroup(group, names) {
+				found[name] = true
+			}
+
+			continue
+		}
+
+		for _, name := range names {
+			if ok, _ := path.Match(item, name); ok || item == name {
+				found[name] = true
+			}
+		}
+	}
+
+	for _, name := range names {
+		if found[name] {
+			matched = append(matched, name)
+		}
+	}
+
+	return matched
+}
+
+// targetConnects returns the connects to run the command on,
+// the hosts of @hosts prefix if set, or the active hosts, the down hosts are skipped.
+func (ps *pShell) targetConnects() (cons []*psConnect) {
+	ps.hostsMu.Lock()
+	defer ps.hostsMu.Unlock()
+
+	for _, c := range ps.Connects {
+		if c.Down != nil {
+			continue
+		}
+
+		if ps.target != nil && ps.target[c.Name] || ps.target == nil && !c.Inactive {
+			cons = append(cons, c)
+		}
+	}
+
+	return cons
+}
+
+// targetNames returns the names of targetConnects.
+func (ps *pShell) targetNames() (names []string) {
+	for _, c := range ps.targetConnects() {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+func (ps *pShell) connectNames() (names []string) {
+	for _, c := range ps.Connects {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// markDown marks the connect down, the following commands skip it until %add again.
+func (ps *pShell) markDown(c *psConnect, err error) {
+	ps.hostsMu.Lock()
+	defer ps.hostsMu.Unlock()
+
+	if c.Down == nil {
+		c.Down = err
+		fmt.Fprintf(os.Stderr, "[%s] disconnected: %v, use %%add %s to reconnect\n", c.Name, err, c.Name)
+	}
+}
+
+// checkAlive marks the connect down if the command failed without exit status and the connection is lost.
+func (ps *pShell) checkAlive(c *psConnect, err error) {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return
+	}
+
+	if _, _, e := c.Client.SendRequest("keepalive@openssh.com", true, nil); e != nil {
+		ps.markDown(c, err)
+	}
+}
+
+// parseTarget parses the @hosts prefix of the command, like `@web1,web3 cmd` or `@group:db cmd`.
+func (ps *pShell) parseTarget(command string) (rest string, target map[string]bool, err error) {
+	if !strings.HasPrefix(command, "@") {
+		return command, nil, nil
+	}
+
+	expr, rest, _ := strings.Cut(command[1:], " ")
+	names := matchHosts(&ps.sshRun.Conf, expr, ps.connectNames())
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("no host matches @%s", expr)
+	}
+
+	target = map[string]bool{}
+	for _, name := range names {
+		target[name] = true
+	}
+
+	return strings.TrimSpace(rest), target, nil
+}
+
+// buildinHosts prints the hosts and their states.
+func (ps *pShell) buildinHosts(out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	stdout := setOutput(out)
+
+	ps.hostsMu.Lock()
+	defer ps.hostsMu.Unlock()
+
+	for _, c := range ps.Connects {
+		state := "active"
+
+		switch {
+		case c.Down != nil:
+			state = "down: " + c.Down.Error()
+		case c.Inactive:
+			state = "inactive"
+		}
+
+		fmt.Fprintf(stdout, "%s\t%s\n", c.Name, state)
+	}
+}
+
+// buildinAdd adds the servers to the active hosts, connects them if not connected or down.
+// example:
+//   - %add web1
+//   - %add web*,group:db
+func (ps *pShell) buildinAdd(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: %%add <server>\n")
+		return
+	}
+
+	r := ps.sshRun
+	expr := strings.Join(args, ",")
+	names := matchHosts(&r.Conf, expr, r.Conf.GetNameSortedList())
+
+	// direct servers, like user@host:port
+	for _, item := range strings.Split(expr, ",") {
+		if conf.IsDirectServer(item) {
+			names = append(names, item)
+		}
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "no server matches %s\n", expr)
+		return
+	}
+
+	for _, name := range names {
+		if c := ps.findConnect(name); c != nil && c.Down == nil {
+			c.Inactive = false
+			continue
+		}
+
+		if !ps.hasServer(name) {
+			ps.ServerList = append(ps.ServerList, name)
+			r.createAuthMethodMapForServer(name)
+		}
+
+		c := r.createPsConnect(ps.config, name, ps.ServerList)
+		if c.Down != nil {
+			fmt.Fprintf(os.Stderr, "[%s] connect error: %v\n", name, c.Down)
+		}
+
+		ps.hostsMu.Lock()
+		if i := ps.connectIndex(name); i >= 0 {
+			ps.Connects[i] = c
+		} else {
+			ps.Connects = append(ps.Connects, c)
+		}
+		ps.hostsMu.Unlock()
+	}
+
+	// refresh the output prompts for the new server list.
+	for _, c := range ps.Connects {
+		c.Output.ServerList = ps.ServerList
+		ps.Options.applyOutput(c)
+	}
+}
+
+// buildinDrop drops the servers from the active hosts, the connections are kept.
+func (ps *pShell) buildinDrop(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: %%drop <server>\n")
+		return
+	}
+
+	names := matchHosts(&ps.sshRun.Conf, strings.Join(args, ","), ps.connectNames())
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "no host matches %s\n", strings.Join(args, ","))
+		return
+	}
+
+	for _, name := range names {
+		ps.findConnect(name).Inactive = true
+	}
+}
+
+// buildinOnly sets the active hosts to the hosts matched by expr.
+func (ps *pShell) buildinOnly(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: %%only <expr>\n")
+		return
+	}
+
+	names := matchHosts(&ps.sshRun.Conf, strings.Join(args, ","), ps.connectNames())
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "no host matches %s\n", strings.Join(args, ","))
+		return
+	}
+
+	active := map[string]bool{}
+	for _, name := range names {
+		active[name] = true
+	}
+
+	for _, c := range ps.Connects {
+		c.Inactive = !active[c.Name]
+	}
+}
+
+func (ps *pShell) findConnect(name string) *psConnect {
+	if i := ps.connectIndex(name); i >= 0 {
+		return ps.Connects[i]
+	}
+
+	return nil
+}
+
+func (ps *pShell) connectIndex(name string) int {
+	for i, c := range ps.Connects {
+		if c.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (ps *pShell) hasServer(name string) bool {
+	for _, s := range ps.ServerList {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
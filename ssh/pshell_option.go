@@ -0,0 +1,231 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/bingoohuang/ngg/ss"
+	"golang.org/x/term"
+)
+
+// Color modes of the server names in pshell.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// newPShellOption creates the options from the [shell] config.
+func newPShellOption(config conf.ShellConfig) pShellOption {
+	return pShellOption{
+		Header:   config.Header.Get(),
+		Collapse: config.Collapse,
+		Timeout:  config.Timeout.Duration,
+		TrackCd:  config.TrackCd,
+		Color:    parseColorMode(config.Color),
+	}
+}
+
+func parseColorMode(s string) string {
+	switch s = strings.ToLower(s); s {
+	case colorAlways, colorNever:
+		return s
+	default:
+		return colorAuto
+	}
+}
+
+// applyOutput applies the header and color options to the output of the connect.
+func (o pShellOption) applyOutput(c *psConnect) {
+	c.Output.DisableHeader = !o.Header
+
+	switch o.Color {
+	case colorAlways:
+		c.Output.AutoColor = true
+	case colorNever:
+		c.Output.AutoColor = false
+	default:
+		c.Output.AutoColor = term.IsTerminal(int(os.Stdout.Fd()))
+	}
+
+	c.Output.Create(c.Name)
+}
+
+// String prints the options in the %set format.
+func (o pShellOption) String() string {
+	onOff := func(b bool) string {
+		if b {
+			return "on"
+		}
+
+		return "off"
+	}
+
+	return fmt.Sprintf("header %s\ncollapse %s\ntimeout %s\ntrackcd %s\ncolor %s\n",
+		onOff(o.Header), onOff(o.Collapse), o.Timeout, onOff(o.TrackCd), o.Color)
+}
+
+// set sets the option of name to value.
+func (o *pShellOption) set(name, value string) error {
+	parseOnOff := func() (bool, error) {
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			return true, nil
+		case "off", "false", "no", "0":
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: invalid value %q, on or off", name, value)
+	}
+
+	var err error
+
+	switch name {
+	case "header":
+		o.Header, err = parseOnOff()
+	case "collapse":
+		o.Collapse, err = parseOnOff()
+	case "trackcd":
+		o.TrackCd, err = parseOnOff()
+	case "timeout":
+		if n, e := strconv.Atoi(value); e == nil {
+			o.Timeout = time.Duration(n) * time.Second
+		} else if d, e := time.ParseDuration(value); e == nil {
+			o.Timeout = d
+		} else {
+			err = fmt.Errorf("timeout: invalid duration %q, like 30s", value)
+		}
+	case "color":
+		if parseColorMode(value) != strings.ToLower(value) {
+			return fmt.Errorf("color: invalid value %q, auto, always or never", value)
+		}
+
+		o.Color = parseColorMode(value)
+	default:
+		return fmt.Errorf("unknown option %q, header, collapse, timeout, trackcd or color", name)
+	}
+
+	return err
+}
+
+// buildinSet prints or sets the options.
+// example:
+//   - %set                 ... print the options
+//   - %set header off
+//   - %set timeout 30s
+func (ps *pShell) buildinSet(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	if len(args) == 0 {
+		fmt.Fprint(setOutput(out), ps.Options.String())
+		return
+	}
+
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %%set [header|collapse|trackcd on|off] [timeout 30s] [color auto|always|never]\n")
+		return
+	}
+
+	if err := ps.Options.set(args[0], args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	for _, c := range ps.Connects {
+		ps.Options.applyOutput(c)
+	}
+}
+
+// buildinSave writes the outputs of history num to the files of the hosts, path/<server>.txt.
+func (ps *pShell) buildinSave(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %%save <num> <path>\n")
+		return
+	}
+
+	num, err := strconv.Atoi(args[0])
+	if err != nil || len(ps.History[num]) == 0 {
+		fmt.Fprintf(os.Stderr, "no history output of %s\n", args[0])
+		return
+	}
+
+	dir := ss.ExpandHome(args[1])
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "mkdir %s error: %v\n", dir, err)
+		return
+	}
+
+	servers := make([]string, 0, len(ps.History[num]))
+	for server := range ps.History[num] {
+		servers = append(servers, server)
+	}
+
+	sort.Strings(servers)
+
+	for _, server := range servers {
+		h := ps.History[num][server]
+		file := filepath.Join(dir, logServerName(server)+".txt")
+
+		if err := os.WriteFile(file, []byte(h.Result), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "write %s error: %v\n", file, err)
+			continue
+		}
+
+		fmt.Fprintf(setOutput(out), "saved %s\n", file)
+	}
+}
+
+// withCwd prefixes the command with cd to the tracked directory of the connect.
+func (ps *pShell) withCwd(c *psConnect, command string) string {
+	if !ps.Options.TrackCd || c.Cwd == "" {
+		return command
+	}
+
+	return "cd " + common.ShellQuote(c.Cwd) + " && " + command
+}
+
+// trackCd changes the tracked directories of the hosts, by `cd dir && pwd` on each host.
+func (ps *pShell) trackCd(args []string, ch chan<- bool) {
+	defer func() { ch <- true }()
+
+	command := strings.TrimSpace("cd " + strings.Join(args, " "))
+	exit := make(chan bool)
+	cons := ps.targetConnects()
+
+	for _, c := range cons {
+		go func() {
+			defer func() { exit <- true }()
+
+			s, err := c.CreateSession()
+			if err != nil {
+				ps.markDown(c, err)
+				return
+			}
+
+			var stdout, stderr bytes.Buffer
+			s.Stdout, s.Stderr = &stdout, &stderr
+
+			if err := s.Run(ps.withCwd(c, command+" && pwd")); err != nil {
+				fmt.Fprintf(os.Stderr, "[%s] %s: %s\n", c.Name, command, strings.TrimSpace(stderr.String()))
+				return
+			}
+
+			c.Cwd = strings.TrimSpace(stdout.String())
+		}()
+	}
+
+	for range cons {
+		<-exit
+	}
+}
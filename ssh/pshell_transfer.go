@@ -0,0 +1,306 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/bssh/output"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/pkg/sftp"
+)
+
+// transferOPrompt is the output prompt of %get and %put, the same as bssh scp.
+const transferOPrompt = "${SERVER} :: "
+
+// sftpClient returns the sftp client over the ssh connection, created on the first use.
+func (c *psConnect) sftpClient() (*sftp.Client, error) {
+	c.sftpOnce.Do(func() {
+		c.sftp, c.sftpErr = sftp.NewClient(c.Client)
+	})
+
+	return c.sftp, c.sftpErr
+}
+
+// parseTransferArgs parses the args of %get and %put, [-H host1,host2] paths...
+func parseTransferArgs(args []string) (hosts, paths []string) {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-H" && i+1 < len(args) {
+			i++
+			hosts = append(hosts, strings.Split(args[i], ",")...)
+			continue
+		}
+
+		paths = append(paths, args[i])
+	}
+
+	return hosts, paths
+}
+
+// transferConnects returns the connects of hosts, the active connects if hosts is empty.
+func (ps *pShell) transferConnects(hosts []string) (cons []*psConnect) {
+	if len(hosts) == 0 {
+		return ps.targetConnects()
+	}
+
+	for _, c := range ps.Connects {
+		if c.Down == nil && ss.AnyOf(c.Name, hosts...) {
+			cons = append(cons, c)
+		}
+	}
+
+	return cons
+}
+
+// fanOut runs fn on each connect in parallel, with the progress bars like bssh scp.
+func (ps *pShell) fanOut(cons []*psConnect, fn func(c *psConnect, ftp *sftp.Client, o *output.Output)) {
+	progress := output.NewProgress(false, os.Stdout)
+
+	var names []string
+	for _, c := range cons {
+		names = append(names, c.Name)
+	}
+
+	exit := make(chan bool)
+
+	for _, c := range cons {
+		go func() {
+			defer func() { exit <- true }()
+
+			o := &output.Output{
+				Templete: transferOPrompt, ServerList: names, Conf: c.Output.Conf,
+				AutoColor: true, Progress: progress,
+			}
+			o.Create(c.Name)
+
+			ftp, err := c.sftpClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "sftp.NewClient %s create client error: %v\n", c.Name, err)
+				return
+			}
+
+			fn(c, ftp, o)
+		}()
+	}
+
+	for range cons {
+		<-exit
+	}
+
+	progress.Wait()
+
+	// wait 0.3 sec
+	time.Sleep(300 * time.Millisecond)
+}
+
+// buildinPut puts the local files or directories to the hosts over sftp.
+// example:
+//   - %put local... remote
+//   - %put -H web1,web2 local... remote
+func (ps *pShell) buildinPut(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	hosts, paths := parseTransferArgs(args)
+	if len(paths) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %%put [-H host1,host2] local... remote\n")
+		return
+	}
+
+	sources, target := paths[:len(paths)-1], paths[len(paths)-1]
+
+	var files []string
+
+	var bases []string
+
+	for _, source := range sources {
+		source = ss.ExpandHome(source)
+
+		data, err := common.WalkDir(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "common.WalkDir error %v\n", err)
+			continue
+		}
+
+		sort.Strings(data)
+
+		for _, f := range data {
+			files = append(files, f)
+			bases = append(bases, filepath.Dir(source))
+		}
+	}
+
+	ps.fanOut(ps.transferConnects(hosts), func(c *psConnect, ftp *sftp.Client, o *output.Output) {
+		ow := o.NewWriter()
+		defer ow.Close()
+
+		for i, f := range files {
+			rel, _ := filepath.Rel(bases[i], f)
+			rpath := path.Join(target, filepath.ToSlash(rel))
+
+			if err := putPath(ftp, o, ps.sshRun.RateLimit(), f, rpath); err != nil {
+				fmt.Fprintf(ow, "put %s->%s error %v\n", f, rpath, err)
+			}
+		}
+	})
+
+	fmt.Println("all put exit.")
+}
+
+func putPath(ftp *sftp.Client, o *output.Output, limit *common.RateLimit, local, remote string) (err error) {
+	fInfo, err := os.Lstat(local)
+	if err != nil {
+		return err
+	}
+
+	if fInfo.IsDir() {
+		if err := ftp.MkdirAll(remote); err != nil {
+			return err
+		}
+
+		return ftp.Chmod(remote, fInfo.Mode())
+	}
+
+	event := audit.Start(audit.ActionSftpPut, o.Server)
+	event.Source, event.Target, event.Size = local, o.Server+":"+remote, fInfo.Size()
+
+	defer func() { event.End(err) }()
+
+	lf, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+
+	defer lf.Close()
+
+	if err := ftp.MkdirAll(path.Dir(remote)); err != nil {
+		return err
+	}
+
+	rf, err := ftp.OpenFile(remote, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	defer rf.Close()
+
+	if err := o.ProgressPrinter(fInfo.Size(), io.TeeReader(limit.Reader(o.Server, lf), rf), remote); err != nil {
+		return err
+	}
+
+	return ftp.Chmod(remote, fInfo.Mode())
+}
+
+// buildinGet gets the remote files or directories from the hosts over sftp,
+// into the per-host directories local/<server> if more than one host.
+// example:
+//   - %get remote... local
+//   - %get -H web1,web2 remote... local
+func (ps *pShell) buildinGet(args []string, out *io.PipeWriter, ch chan<- bool) {
+	defer ps.closeBuildin(out, ch)
+
+	hosts, paths := parseTransferArgs(args)
+	if len(paths) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %%get [-H host1,host2] remote... local\n")
+		return
+	}
+
+	sources, target := paths[:len(paths)-1], ss.ExpandHome(paths[len(paths)-1])
+	cons := ps.transferConnects(hosts)
+
+	ps.fanOut(cons, func(c *psConnect, ftp *sftp.Client, o *output.Output) {
+		ow := o.NewWriter()
+		defer ow.Close()
+
+		baseDir := target
+		if len(cons) > 1 {
+			baseDir = filepath.Join(baseDir, c.Name)
+		}
+
+		if err := os.MkdirAll(baseDir, 0o755); err != nil {
+			fmt.Fprintf(ow, "os.MkdirAll error %v\n", err)
+			return
+		}
+
+		for _, source := range sources {
+			globpath, err := ftp.Glob(source)
+			if err != nil || len(globpath) == 0 {
+				fmt.Fprintf(ow, "no such file %s\n", source)
+				continue
+			}
+
+			for _, gp := range globpath {
+				remoteBase := path.Dir(gp)
+
+				for walker := ftp.Walk(gp); walker.Step(); {
+					if err := walker.Err(); err != nil {
+						fmt.Fprintf(ow, "walker.Err Error: %v\n", err)
+						continue
+					}
+
+					p := walker.Path()
+					if common.IsHidden(remoteBase, p) {
+						continue // ignore hidden files.
+					}
+
+					rel, _ := filepath.Rel(remoteBase, p)
+					if err := getPath(ftp, o, ps.sshRun.RateLimit(), walker.Stat(), p, filepath.Join(baseDir, rel)); err != nil {
+						fmt.Fprintf(ow, "get %s error %v\n", p, err)
+					}
+				}
+			}
+		}
+	})
+
+	fmt.Println("all get exit.")
+}
+
+func getPath(ftp *sftp.Client, o *output.Output, limit *common.RateLimit, stat os.FileInfo, remote, local string) (err error) {
+	if stat.IsDir() {
+		if err := os.MkdirAll(local, 0o755); err != nil {
+			return err
+		}
+
+		return os.Chmod(local, stat.Mode())
+	}
+
+	event := audit.Start(audit.ActionSftpGet, o.Server)
+	event.Source, event.Target, event.Size = o.Server+":"+remote, local, stat.Size()
+
+	defer func() { event.End(err) }()
+
+	rf, err := ftp.Open(remote)
+	if err != nil {
+		return err
+	}
+
+	defer rf.Close()
+
+	lf, err := os.OpenFile(local, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	defer lf.Close()
+
+	if err := o.ProgressPrinter(stat.Size(), io.TeeReader(limit.Reader(o.Server, rf), lf), remote); err != nil {
+		return err
+	}
+
+	return os.Chmod(local, stat.Mode())
+}
+
+// closeBuildin closes the out pipe and sends the exit of the build-in command.
+func (ps *pShell) closeBuildin(out *io.PipeWriter, ch chan<- bool) {
+	if out != nil {
+		_ = out.CloseWithError(io.ErrClosedPipe)
+	}
+
+	ch <- true
+}
@@ -7,7 +7,9 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/misc"
 	"github.com/bingoohuang/bssh/sshlib"
@@ -63,6 +65,9 @@ type Run struct {
 	EnableHeader  bool
 	DisableHeader bool
 
+	// print the identical outputs of hosts once, after the command exits (--collapse option)
+	Collapse bool
+
 	// StdinData from pipe flag
 	isStdinPipe bool
 
@@ -90,7 +95,12 @@ type Run struct {
 	// Map of AuthMethod used by target server
 	serverAuthMethodMap map[string][]ssh.AuthMethod
 
+	// rateLimit is the bandwidth limit shared by the transfers of the run.
+	rateLimit     *common.RateLimit
+	rateLimitOnce sync.Once
+
 	decodedPasswordMap map[string]bool
+	runID              string
 	confFile           string
 	webPort            int
 }
@@ -105,6 +115,18 @@ func NewRun(confFile string) *Run {
 	return r
 }
 
+// RateLimit returns the bandwidth limit of the run, the total limit is shared by all its servers.
+// A nil Run limits by the RATELIMIT env only.
+func (r *Run) RateLimit() *common.RateLimit {
+	if r == nil {
+		return nil
+	}
+
+	r.rateLimitOnce.Do(func() { r.rateLimit = r.Conf.NewRateLimit() })
+
+	return r.rateLimit
+}
+
 // AuthKey define auth key.
 type AuthKey struct {
 	// auth type:
@@ -228,11 +250,23 @@ func (r *Run) printDynamicPortForward(port string) {
 // printProxy is printout proxy route.
 // use ssh command run header. only use shell().
 func (r *Run) printProxy(server string) {
+	route, err := ProxyRoute(server, r.Conf)
+	if err != nil || route == "" {
+		return
+	}
+
+	// print header
+	fmt.Fprintf(os.Stderr, "Proxy         :%s\n", route)
+}
+
+// ProxyRoute returns the proxy route of server, like "localhost => [ssh://jump:22] => server".
+// It returns an empty string if the server is connected directly.
+func ProxyRoute(server string, config conf.Config) (string, error) {
 	var array []string
 
-	proxyRoute, err := getProxyRoute(server, r.Conf)
+	proxyRoute, err := getProxyRoute(server, config)
 	if err != nil || len(proxyRoute) == 0 {
-		return
+		return "", err
 	}
 
 	// set localhost
@@ -267,9 +301,7 @@ func (r *Run) printProxy(server string) {
 	// add target
 	array = append(array, targethost)
 
-	// print header
-	header := strings.Join(array, " => ")
-	fmt.Fprintf(os.Stderr, "Proxy         :%s\n", header)
+	return strings.Join(array, " => "), nil
 }
 
 func (r *Run) registerAutoEncryptPwd(oldPwd string) {
@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/bingoohuang/bssh/sshlib"
+	"go.uber.org/atomic"
+)
+
+// runLog records the output of cmd and pshell runs to the log files of hosts,
+// or to one combined log with the host prefixes if [log] combined = true.
+type runLog struct {
+	r        *Run
+	mode     string
+	toggle   *atomic.Bool
+	redactor *sshlib.Redactor
+
+	mu       sync.Mutex
+	writers  map[string]io.Writer
+	files    []*os.File
+	combined io.Writer
+}
+
+// combinedLogName is the server name part of the combined log file.
+const combinedLogName = "combined"
+
+// newRunLog opens the log files of r.ServerList for the mode (cmd or pshell), returns nil if logging is disabled.
+func (r *Run) newRunLog(mode string) *runLog {
+	if !r.Conf.Log.Enable {
+		return nil
+	}
+
+	l := &runLog{
+		r: r, mode: mode, toggle: atomic.NewBool(true), redactor: r.Conf.Redact.Redactor(),
+		writers: map[string]io.Writer{},
+	}
+
+	for _, server := range r.ServerList {
+		l.Writer(server)
+	}
+
+	return l
+}
+
+func (l *runLog) open(server string) io.Writer {
+	logPath := l.r.getRunLogPath(server, l.mode)
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		log.Printf("open log %s error: %v", logPath, err)
+		return nil
+	}
+
+	if len(l.files) == 0 {
+		fmt.Printf("logging run %s to %s\n", l.r.RunID(), logPath)
+	}
+
+	l.files = append(l.files, f)
+
+	return f
+}
+
+// Writer returns the log writer of server, it is safe to call on nil.
+func (l *runLog) Writer(server string) io.Writer {
+	if l == nil {
+		return io.Discard
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if w, ok := l.writers[server]; ok {
+		return w
+	}
+
+	var f io.Writer
+	prefix := ""
+
+	if l.r.Conf.Log.Combined {
+		if l.combined == nil {
+			l.combined = l.open(combinedLogName)
+		}
+
+		f, prefix = l.combined, "["+server+"] "
+	} else {
+		f = l.open(server)
+	}
+
+	var w io.Writer = io.Discard
+	if f != nil {
+		// the prefixWriter is not a closer, the files are closed by Close.
+		w = sshlib.NewLogWrite(&prefixWriter{prefix: prefix, w: f}, l.toggle, l.r.Conf.Log.Timestamp, false, l.redactor)
+	}
+
+	l.writers[server] = w
+
+	return w
+}
+
+// Close writes the incomplete last lines and closes the log files, it is safe to call on nil.
+func (l *runLog) Close() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, w := range l.writers {
+		if c, ok := w.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+
+	for _, f := range l.files {
+		_ = f.Close()
+	}
+
+	l.writers, l.files, l.combined = map[string]io.Writer{}, nil, nil
+}
+
+// Printf writes a note line, like the running command, to all the log files.
+func (l *runLog) Printf(format string, a ...any) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line := l.redactor.Redact(fmt.Sprintf(format, a...))
+	for _, f := range l.files {
+		_, _ = io.WriteString(f, line)
+	}
+}
+
+// prefixWriter adds the prefix to each write, the log writer writes line by line.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if p.prefix == "" {
+		return p.w.Write(b)
+	}
+
+	if _, err := p.w.Write(append([]byte(p.prefix), b...)); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
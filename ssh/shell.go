@@ -2,7 +2,6 @@ package ssh
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/bssh/common"
 	"github.com/bingoohuang/bssh/conf"
 	"github.com/bingoohuang/bssh/internal/stash"
@@ -64,6 +64,10 @@ func (r *Run) shell() (err error) {
 	if err != nil {
 		return err
 	}
+
+	event := audit.Start(audit.ActionShell, serverID)
+	defer func() { event.End(err) }()
+
 	r.overwritePortForwardConfig(config)
 	r.overwriteBashrcConfig(config)
 
@@ -105,11 +109,16 @@ func (r *Run) shell() (err error) {
 		r.Conf.WriteTempHosts(serverID, config)
 	}
 
+	r.Conf.UpdateHostInfo(serverID, func(h *conf.HostInfo) {
+		h.LastUsed = time.Now().Format("2006-01-02 15:04:05")
+	})
+
 	r.sshAgent(config, connect, session)
 
 	err = r.portForwarding(config, connect)
 
 	if config.DynamicPortForward != "" { // Dynamic Port Forwarding
+		auditForward(serverID, "D", config.DynamicPortForward, "", nil)
 		go func() {
 			if err := connect.TCPDynamicForward("localhost", config.DynamicPortForward); err != nil {
 				fmt.Println(err)
@@ -140,6 +149,9 @@ func (r *Run) shell() (err error) {
 			logPath := r.getLogPath(serverID)
 			fmt.Printf("logging to %s\n", logPath)
 			connect.SetLog(logPath, logConf.Timestamp)
+			connect.LogFormat = logConf.Format
+			connect.LogTitle = serverID
+			connect.LogRedactor = r.Conf.Redact.Redactor()
 		}
 
 		// TDXX(blacknon): local rc file add
@@ -163,16 +175,10 @@ func (r *Run) shell() (err error) {
 						return
 					}
 
-					r.Conf.HostInfo[serverID] = conf.HostInfo{
-						Info:   hostInfo,
-						Update: time.Now().Format("2006-01-02 15:04:05"),
-					}
-					hostInfoJson, _ := json.Marshal(r.Conf.HostInfo)
-					if len(hostInfoJson) > 0 {
-						if err := os.WriteFile(r.Conf.HostInfoJsonFile, hostInfoJson, os.ModePerm); err != nil {
-							log.Printf("write %q error: %v", r.Conf.HostInfoJsonFile, err)
-						}
-					}
+					r.Conf.UpdateHostInfo(serverID, func(h *conf.HostInfo) {
+						h.Info = hostInfo
+						h.Update = time.Now().Format("2006-01-02 15:04:05")
+					})
 				}, processInfoScript)
 		}
 	}
@@ -346,6 +352,8 @@ func (r *Run) portForwarding(config *conf.ServerConfig, connect *sshlib.Connect)
 			err = connect.TCPRemoteForward(config.PortForwardLocal, config.PortForwardRemote)
 		}
 
+		auditForward(config.ID, config.PortForwardMode, config.PortForwardLocal, config.PortForwardRemote, err)
+
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -354,13 +362,20 @@ func (r *Run) portForwarding(config *conf.ServerConfig, connect *sshlib.Connect)
 	return err
 }
 
-// getLogPath return log file path.
-func (r *Run) getLogPath(server string) (logPath string) {
-	if idx := strings.Index(server, "@"); idx >= 0 {
-		server = server[idx+1:]
+// auditForward records the port forwarding opened on the server, mode is L, R or D.
+func auditForward(server, mode, local, remote string, err error) {
+	event := audit.Start(audit.ActionForward, server)
+	event.Command = "-" + ss.Or(mode, "L") + " " + local
+	if remote != "" {
+		event.Command += ":" + remote
 	}
 
-	server = strings.ReplaceAll(server, ":", "_")
+	event.End(err)
+}
+
+// getLogPath return log file path.
+func (r *Run) getLogPath(server string) (logPath string) {
+	server = logServerName(server)
 	dir, dateFound, serverFound, err := r.getLogDirPath(server)
 	if err != nil {
 		log.Println(err)
@@ -372,6 +387,15 @@ func (r *Run) getLogPath(server string) (logPath string) {
 		file = time.Now().Format("20060102")
 	}
 
+	// asciicast can not be appended, one file per session.
+	isCast := r.Conf.Log.Format == sshlib.LogFormatAsciicast
+	if isCast {
+		if file != "" {
+			file += "_"
+		}
+		file += time.Now().Format("150405")
+	}
+
 	if !serverFound {
 		if file != "" {
 			file += "_"
@@ -379,12 +403,52 @@ func (r *Run) getLogPath(server string) (logPath string) {
 		file += server
 	}
 
-	file += ".log"
+	file += ss.If(isCast, ".cast", ".log")
 	logPath = filepath.Join(dir, file)
 
 	return logPath
 }
 
+// getRunLogPath return the log file path of cmd and pshell runs, like 20240102_web01@cmd-150405-ab3d.log.
+func (r *Run) getRunLogPath(server, mode string) string {
+	server = logServerName(server)
+	dir, dateFound, serverFound, err := r.getLogDirPath(server)
+	if err != nil {
+		log.Println(err)
+	}
+
+	var parts []string
+	if !dateFound {
+		parts = append(parts, time.Now().Format("20060102"))
+	}
+
+	if !serverFound {
+		parts = append(parts, server)
+	}
+
+	file := strings.Join(parts, "_") + "@" + mode + "-" + r.RunID() + ".log"
+
+	return filepath.Join(dir, file)
+}
+
+// logServerName returns the server name used in the log path, user@host:port => host_port.
+func logServerName(server string) string {
+	if idx := strings.Index(server, "@"); idx >= 0 {
+		server = server[idx+1:]
+	}
+
+	return strings.ReplaceAll(server, ":", "_")
+}
+
+// RunID returns the id of this run, like 150405-ab3d, to tell the logs of the cmd and pshell runs.
+func (r *Run) RunID() string {
+	if r.runID == "" {
+		r.runID = time.Now().Format("150405") + "-" + strings.ToLower(common.RandomString(4))
+	}
+
+	return r.runID
+}
+
 // getLogDirPath return log directory path.
 func (r *Run) getLogDirPath(server string) (dir string, dateFound, hostnameFound bool, err error) {
 	logConf := r.Conf.Log
@@ -393,6 +457,7 @@ func (r *Run) getLogDirPath(server string) (dir string, dateFound, hostnameFound
 	dir = ss.ExpandHome(logConf.Dir)
 	dir, dateFound = Replace(dir, "<Date>", time.Now().Format("20060102"), 1)
 	dir, hostnameFound = Replace(dir, "<ServerName>", server, 1)
+	dir, _ = Replace(dir, "<RunID>", r.RunID(), 1)
 
 	// create directory
 	err = os.MkdirAll(dir, 0o700)
@@ -1,10 +1,19 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
-	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
 	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bingoohuang/bssh/conf"
+	"github.com/bingoohuang/bssh/output"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestPemFile(t *testing.T) {
@@ -57,3 +66,176 @@ func TestPemFile(t *testing.T) {
 	// 输出命令执行结果
 	fmt.Println(string(output))
 }
+
+func TestProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	config := conf.Config{Server: map[string]conf.ServerConfig{
+		"up":      {Addr: host, Port: port},
+		"badjump": {Addr: host, Port: port, Proxy: "missing"},
+		"jump":    {Addr: host, Port: port},
+		"behind":  {Addr: "192.0.2.1", Port: "22", Proxy: "jump"},
+	}}
+
+	if _, err := Probe("up", config, false, time.Second); err != nil {
+		t.Errorf("probe up: %v", err)
+	}
+
+	// the server behind the ssh proxy is unknown, not reported as reachable.
+	var via *ViaError
+	if _, err := Probe("behind", config, false, time.Second); !errors.As(err, &via) || via.Proxy != "jump" {
+		t.Errorf("probe behind: expect via jump, got %v", err)
+	}
+
+	if _, err := Probe("badjump", config, false, time.Second); err == nil {
+		t.Error("probe badjump: expect error")
+	}
+
+	if _, err := Probe("unknown", config, false, time.Second); err == nil {
+		t.Error("probe unknown: expect error")
+	}
+}
+
+func TestRunLog(t *testing.T) {
+	dir := t.TempDir()
+	r := &Run{ServerList: []string{"web01", "web02"}}
+	r.Conf.Log = conf.LogConfig{Enable: true, Dir: dir + "/<Date>", Combined: true}
+	r.Conf.Redact = conf.RedactConfig{Builtin: true}
+
+	l := r.newRunLog("cmd")
+	l.Printf("$ uptime\n")
+	_, _ = l.Writer("web01").Write([]byte("up 1 day, TOKEN=abc\n"))
+	_, _ = l.Writer("web02").Write([]byte("up 2 days"))
+	l.Close()
+
+	logPath := r.getRunLogPath(combinedLogName, "cmd")
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	sort.Strings(lines[1:])
+	want := []string{"$ uptime", "[web01] up 1 day, TOKEN=******", "[web02] up 2 days"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("combined log %s:\n%s", logPath, data)
+	}
+
+	if !strings.HasSuffix(logPath, "combined@cmd-"+r.RunID()+".log") {
+		t.Errorf("log path %s", logPath)
+	}
+}
+
+func TestPshellDiff(t *testing.T) {
+	groups := output.GroupOutputs([]string{"web1", "web2", "web3", "web4"}, map[string]string{
+		"web1": "a\nb\n", "web2": "a\nc\n", "web3": "a\nc\n", "web4": "a\nc\n",
+	})
+	if len(groups) != 2 || strings.Join(groups[0].Servers, ",") != "web2,web3,web4" || groups[1].Servers[0] != "web1" {
+		t.Errorf("GroupOutputs: unexpected groups %v %v", groups[0], groups[1])
+	}
+
+	// web2 dropped from the server list after the command.
+	hosts := historyHosts([]string{"web1", "web3"}, map[string]string{"web2": "a", "web3": "a"})
+	if strings.Join(hosts, ",") != "web3,web2" {
+		t.Errorf("historyHosts: unexpected hosts %v", hosts)
+	}
+
+	var b strings.Builder
+	writeUnifiedDiff(&b, "web2", "a\nc\n", "web1", "a\nb\n", false)
+	if want := "--- web2\n+++ web1\n@@ -1,2 +1,2 @@\n a\n-c\n+b\n"; b.String() != want {
+		t.Errorf("writeUnifiedDiff: got %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	writeSideBySide(&b, "web2", "a\nc\nd\n", "web1", "a\nb\n", 23, false)
+	if want := "web2         web1\n----------   ----------\na            a\nc          | b\nd          <\n"; b.String() != want {
+		t.Errorf("writeSideBySide: got %q, want %q", b.String(), want)
+	}
+}
+
+func TestParseTransferArgs(t *testing.T) {
+	hosts, paths := parseTransferArgs([]string{"-H", "web1,web2", "a.txt", "dir", "/tmp"})
+	if strings.Join(hosts, ",") != "web1,web2" || strings.Join(paths, ",") != "a.txt,dir,/tmp" {
+		t.Errorf("parseTransferArgs: got %v %v", hosts, paths)
+	}
+
+	ps := &pShell{Connects: []*psConnect{{Name: "web1"}, {Name: "db1", Down: io.EOF}, {Name: "web2"}}}
+	if cons := ps.transferConnects(nil); len(cons) != 2 {
+		t.Errorf("transferConnects: got %d connects, want 2", len(cons))
+	}
+
+	if cons := ps.transferConnects([]string{"web2"}); len(cons) != 1 || cons[0].Name != "web2" {
+		t.Errorf("transferConnects: got %v, want web2", cons)
+	}
+}
+
+func TestPshellTarget(t *testing.T) {
+	cf := &conf.Config{Server: map[string]conf.ServerConfig{
+		"web1": {Group: []string{"prod/web"}}, "web2": {Group: []string{"prod/web"}}, "db1": {Group: []string{"prod/db"}},
+	}}
+	names := []string{"web1", "web2", "db1"}
+
+	if got := strings.Join(matchHosts(cf, "db1,web*", names), ","); got != "web1,web2,db1" {
+		t.Errorf("matchHosts: got %s", got)
+	}
+
+	if got := strings.Join(matchHosts(cf, "group:prod/db", names), ","); got != "db1" {
+		t.Errorf("matchHosts group: got %s", got)
+	}
+
+	ps := &pShell{
+		sshRun:   &Run{Conf: *cf},
+		Connects: []*psConnect{{Name: "web1"}, {Name: "web2", Inactive: true}, {Name: "db1", Down: io.EOF}},
+	}
+
+	if got := strings.Join(ps.targetNames(), ","); got != "web1" {
+		t.Errorf("targetNames: got %s, want web1", got)
+	}
+
+	line, target, err := ps.parseTarget("@web* uptime")
+	if err != nil || line != "uptime" || !target["web1"] || !target["web2"] || len(target) != 2 {
+		t.Errorf("parseTarget: got %q %v %v", line, target, err)
+	}
+
+	ps.target = target
+	if got := strings.Join(ps.targetNames(), ","); got != "web1,web2" {
+		t.Errorf("targetNames with @web*: got %s, want web1,web2", got)
+	}
+
+	if _, _, err := ps.parseTarget("@nohost uptime"); err == nil {
+		t.Errorf("parseTarget: want error of no host matches")
+	}
+}
+
+func TestPshellOption(t *testing.T) {
+	o := newPShellOption(conf.ShellConfig{Color: "Never"})
+	if !o.Header || o.Collapse || o.Color != colorNever {
+		t.Errorf("newPShellOption: got %+v", o)
+	}
+
+	for _, kv := range [][2]string{{"header", "off"}, {"collapse", "on"}, {"timeout", "30"}, {"trackcd", "yes"}, {"color", "always"}} {
+		if err := o.set(kv[0], kv[1]); err != nil {
+			t.Errorf("set %s %s: %v", kv[0], kv[1], err)
+		}
+	}
+
+	if want := "header off\ncollapse on\ntimeout 30s\ntrackcd on\ncolor always\n"; o.String() != want {
+		t.Errorf("String: got %q, want %q", o.String(), want)
+	}
+
+	for _, kv := range [][2]string{{"header", "maybe"}, {"timeout", "soon"}, {"color", "red"}, {"font", "big"}} {
+		if err := o.set(kv[0], kv[1]); err == nil {
+			t.Errorf("set %s %s: want error", kv[0], kv[1])
+		}
+	}
+
+	ps := &pShell{Options: o}
+	if got := ps.withCwd(&psConnect{Cwd: "/var/it's"}, "ls"); got != `cd '/var/it'\''s' && ls` {
+		t.Errorf("withCwd: got %s", got)
+	}
+}
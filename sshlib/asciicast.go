@@ -0,0 +1,232 @@
+package sshlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bingoohuang/ngg/ss"
+	"go.uber.org/atomic"
+	"golang.org/x/term"
+)
+
+// LogFormatAsciicast is the log format to record the terminal session as asciinema v2 file.
+// See https://docs.asciinema.org/manual/asciicast/v2/
+const LogFormatAsciicast = "asciicast"
+
+// AsciicastHeader is the first line of asciicast v2 file.
+type AsciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// asciicastRedactFlush is the idle time to flush the pending line when redacting.
+const asciicastRedactFlush = time.Second
+
+// asciicastWriter records the events of terminal session in asciicast v2 format.
+type asciicastWriter struct {
+	file          io.WriteCloser
+	start         time.Time
+	toggleLogging *atomic.Bool
+	// redactor masks the secrets, the events are recorded line by line to match the secrets across writes.
+	redactor *Redactor
+
+	mu sync.Mutex
+	// pending bytes of the last write and the time of the first pending byte, keyed by event type
+	pending   map[string][]byte
+	pendingAt map[string]float64
+	flusher   *time.Timer
+	lastAt    float64
+	// noEcho is true after a password prompt, the input is not recorded until the enter key.
+	noEcho bool
+	// stopResize stops watching the resize events.
+	stopResize func()
+}
+
+// newAsciicastWriter writes the header to file, and returns the writer of events.
+func newAsciicastWriter(file io.WriteCloser, toggleLogging *atomic.Bool, width, height int, title string) (*asciicastWriter, error) {
+	w := &asciicastWriter{
+		file:          file,
+		start:         time.Now(),
+		toggleLogging: toggleLogging,
+		pending:       make(map[string][]byte),
+		pendingAt:     make(map[string]float64),
+	}
+
+	header := AsciicastHeader{
+		Version: 2, Width: width, Height: height, Timestamp: w.start.Unix(), Title: title,
+		Env: map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// event writes an event line, like [1.001, "o", "data"].
+// The trailing incomplete utf8 bytes (or the incomplete line when redacting) are kept to the next event of the same type.
+func (w *asciicastWriter) event(code string, p []byte) {
+	if w.toggleLogging != nil && !w.toggleLogging.Load() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case code == "o" && len(p) > 0:
+		w.noEcho = IsPasswordPrompt(append(w.pending[code], p...))
+	case code == "i" && w.noEcho:
+		i := bytes.IndexAny(p, "\r\n")
+		if i < 0 {
+			return
+		}
+
+		p, w.noEcho = p[i:], false
+	}
+
+	if len(w.pending[code]) == 0 {
+		w.pendingAt[code] = time.Since(w.start).Seconds()
+	}
+
+	data := append(w.pending[code], p...)
+	cut := utf8Cut(data)
+
+	if w.redactor != nil {
+		// the enter key is \r in the input.
+		if i := bytes.LastIndexAny(data, ss.If(code == "i", "\r\n", "\n")); i >= 0 {
+			cut = i + 1
+		} else if len(data) < 4096 {
+			cut = 0
+		}
+	}
+
+	w.pending[code] = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		w.flushPending(code)
+		w.writeEvent(w.pendingAt[code], code, string(data[:cut]))
+		w.pendingAt[code] = time.Since(w.start).Seconds()
+	}
+
+	if w.redactor != nil && len(w.pending[code]) > 0 {
+		if w.flusher == nil {
+			w.flusher = time.AfterFunc(asciicastRedactFlush, w.flush)
+		} else {
+			w.flusher.Reset(asciicastRedactFlush)
+		}
+	}
+}
+
+// flush writes the pending lines when the session is idle.
+func (w *asciicastWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.flushPending("")
+}
+
+// flushPending writes the pending data of the event types except the code, to keep the events in order.
+func (w *asciicastWriter) flushPending(except string) {
+	for code, data := range w.pending {
+		if cut := utf8Cut(data); code != except && cut > 0 {
+			w.writeEvent(w.pendingAt[code], code, string(data[:cut]))
+			w.pending[code] = append([]byte(nil), data[cut:]...)
+		}
+	}
+}
+
+// utf8Cut returns the length of data without the trailing incomplete utf8 bytes.
+func utf8Cut(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+
+	return len(data)
+}
+
+// writeEvent writes the event, the time is not earlier than the last event.
+func (w *asciicastWriter) writeEvent(at float64, code, data string) {
+	at = max(at, w.lastAt)
+	w.lastAt = at
+
+	line, err := json.Marshal([]any{at, code, w.redactor.Redact(data)})
+	if err == nil {
+		_, _ = w.file.Write(append(line, '\n'))
+	}
+}
+
+// resize writes a resize event, like [1.001, "r", "80x24"].
+func (w *asciicastWriter) resize(width, height int) {
+	if w.toggleLogging != nil && !w.toggleLogging.Load() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writeEvent(time.Since(w.start).Seconds(), "r", strconv.Itoa(width)+"x"+strconv.Itoa(height))
+}
+
+// watchResize records the terminal resize events, until the writer is closed.
+func (w *asciicastWriter) watchResize() {
+	w.stopResize = notifyResize(func() {
+		if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			w.resize(width, height)
+		}
+	})
+}
+
+// Close stops watching the resize events, writes the pending data and closes the file.
+func (w *asciicastWriter) Close() error {
+	if w.stopResize != nil {
+		w.stopResize()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.flusher != nil {
+		w.flusher.Stop()
+	}
+
+	w.flushPending("")
+
+	return w.file.Close()
+}
+
+// Output returns the writer of output events.
+func (w *asciicastWriter) Output() io.Writer { return asciicastEventWriter{w: w, code: "o"} }
+
+// Input returns the writer of input events.
+func (w *asciicastWriter) Input() io.Writer { return asciicastEventWriter{w: w, code: "i"} }
+
+type asciicastEventWriter struct {
+	w    *asciicastWriter
+	code string
+}
+
+func (e asciicastEventWriter) Write(p []byte) (int, error) {
+	e.w.event(e.code, p)
+	return len(p), nil
+}
@@ -0,0 +1,54 @@
+package sshlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestAsciicastWriter(t *testing.T) {
+	var buf bytes.Buffer
+	toggle := atomic.NewBool(true)
+
+	w, err := newAsciicastWriter(nopWriteCloser{&buf}, toggle, 80, 24, "web1")
+	assert.Nil(t, err)
+
+	hello := []byte("你好")
+	_, _ = w.Output().Write(hello[:4]) // split in the middle of a char
+	_, _ = w.Output().Write(hello[4:])
+	_, _ = w.Input().Write([]byte("ls\r"))
+
+	toggle.Store(false)
+	_, _ = w.Input().Write([]byte("secret"))
+	toggle.Store(true)
+
+	w.resize(100, 30)
+
+	scanner := bufio.NewScanner(&buf)
+	assert.True(t, scanner.Scan())
+
+	var header AsciicastHeader
+	assert.Nil(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, 80, header.Width)
+	assert.Equal(t, 24, header.Height)
+	assert.Equal(t, "web1", header.Title)
+
+	var events [][2]string
+	for scanner.Scan() {
+		var event []any
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, [2]string{event[1].(string), event[2].(string)})
+	}
+
+	assert.Equal(t, [][2]string{{"o", "你"}, {"o", "好"}, {"i", "ls\r"}, {"r", "100x30"}}, events)
+}
@@ -54,9 +54,7 @@ func (c *Connect) Command(command string) (err error) {
 	}
 
 	// Run Command
-	c.Session.Run(command)
-
-	return
+	return c.Session.Run(command)
 }
 
 func (c *Connect) setOption(session *ssh.Session) (err error) {
@@ -76,6 +76,16 @@ type Connect struct {
 	// Forward x11 flag.
 	ForwardX11 bool
 
+	// ServerID is the server name in the config, recorded in the audit trail.
+	ServerID string
+
+	// RateLimit limits the bandwidth of the in-band transfers, and the port forwards if LimitForward.
+	RateLimit    func(io.Reader) io.Reader
+	LimitForward bool
+
+	// NewBar creates the progress bar of the in-band transfers of size bytes, nil for no bar.
+	NewBar func(size int64) ProgressBar
+
 	// shell terminal log flag
 	logging bool
 
@@ -85,9 +95,21 @@ type Connect struct {
 	// terminal log path
 	logFile string
 
+	// logCloser closes the terminal log writer when the shell exits.
+	logCloser io.Closer
+
 	// keep ansi code on terminal log.
 	LogKeepAnsiCode bool
 
+	// terminal log format, "text"(default) or "asciicast".
+	LogFormat string
+
+	// title of the asciicast terminal log.
+	LogTitle string
+
+	// LogRedactor masks the secrets in the terminal log, nil to disable.
+	LogRedactor *Redactor
+
 	toggleLogging *atomic.Bool
 }
 
@@ -95,6 +117,35 @@ func (c *Connect) Exit() {
 	c.Session.Close()
 }
 
+// rateLimit limits r by RateLimit if set.
+func (c *Connect) rateLimit(r io.Reader) io.Reader {
+	if c.RateLimit == nil {
+		return r
+	}
+
+	return c.RateLimit(r)
+}
+
+// ProgressBar is the progress bar of the in-band transfers.
+type ProgressBar interface {
+	Add(n int)
+	Finish(err error)
+}
+
+type nopBar struct{}
+
+func (nopBar) Add(int)      {}
+func (nopBar) Finish(error) {}
+
+// newBar creates the progress bar by NewBar if set.
+func (c *Connect) newBar(size int64) ProgressBar {
+	if c.NewBar == nil {
+		return nopBar{}
+	}
+
+	return c.NewBar(size)
+}
+
 // CreateClient set c.Client.
 func (c *Connect) CreateClient(host, port, user string, authMethods []ssh.AuthMethod, brg string) (err error) {
 	uri := net.JoinHostPort(host, port)
@@ -12,15 +12,22 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/ngg/ss"
 	"github.com/bingoohuang/ngg/tsid"
-	"github.com/cheggaaa/pb/v3"
 )
 
 func (i *interruptReader) dl(file string) {
+	event := audit.Start(audit.ActionDownload, i.connect.ServerID)
+	event.Source = file
+
+	var failed error
+	defer func() { event.End(failed) }()
+
 	fileSize, err := i.lsSize(file)
 	if err != nil {
 		log.Printf("ls error: %v", err)
+		failed = err
 		return
 	}
 
@@ -30,28 +37,24 @@ func (i *interruptReader) dl(file string) {
 	tempFile, err := os.CreateTemp("/tmp", "*."+base)
 	if err != nil {
 		log.Printf("create temp file: %v", err)
+		failed = err
 		return
 	}
 	defer tempFile.Close()
 
+	event.Target, event.Size = tempFile.Name(), fileSize
+
 	os.Stdout.Write([]byte(fmt.Sprintf("start to download remote %s to local %s\n",
 		file, tempFile.Name())))
 
 	// create bar
-	bar := pb.New(int(fileSize))
-	// refresh info every second (default 200ms)
-	bar.SetRefreshRate(time.Second)
-	// force set io.Writer, by default it's os.Stderr
-	bar.SetWriter(os.Stdout)
-	// bar will format numbers as bytes (B, KiB, MiB, etc)
-	bar.Set(pb.Bytes, true)
-	bar.Start()
+	bar := i.connect.newBar(fileSize)
 
 	pr, pw := io.Pipe()
 	decoder := base64.NewDecoder(base64.StdEncoding, pr)
 
 	h := md5.New()
-	br := &PbReader{Reader: decoder, bar: bar}
+	br := &PbReader{Reader: i.connect.rateLimit(decoder), bar: bar}
 
 	go func() {
 		if _, err := io.Copy(io.MultiWriter(tempFile, h), br); err != nil && errors.Is(err, io.EOF) {
@@ -65,18 +68,19 @@ func (i *interruptReader) dl(file string) {
 		}
 	}
 
-	bar.Finish()
-
 	dlMd5 := fmt.Sprintf("%x", h.Sum(nil))
 	if dlMd5 != md5sum {
 		os.Stdout.Write([]byte("downloaded failed"))
+		failed = errors.New("md5sum mismatch")
 	}
+
+	bar.Finish(failed)
 }
 
 // PbReader counts the bytes read through it.
 type PbReader struct {
 	io.Reader
-	bar *pb.ProgressBar
+	bar ProgressBar
 }
 
 func (r *PbReader) Read(p []byte) (n int, err error) {
@@ -0,0 +1,108 @@
+package sshlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bingoohuang/bssh/audit"
+	"github.com/bingoohuang/bssh/common"
+	"github.com/bingoohuang/ngg/ss"
+	"github.com/bingoohuang/ngg/tsid"
+)
+
+func (i *interruptReader) edit(file string) {
+	event := audit.Start(audit.ActionEdit, i.connect.ServerID)
+	event.Target = file
+
+	var failed error
+	defer func() { event.End(failed) }()
+
+	if failed = i.editFile(file); failed != nil {
+		log.Printf("edit %s error: %v", file, failed)
+	}
+}
+
+// editFile edits the remote file by the in-band transfers, it is written back in place so its mode and owner are kept.
+func (i *interruptReader) editFile(file string) error {
+	saved, err := common.EditRemote(file,
+		func() ([]byte, error) { return i.readFile(file) },
+		func(data []byte) error { return i.writeFile(file, data) })
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\r\n", file, ss.If(saved, "saved", "not changed"))
+
+	return nil
+}
+
+// readFile reads the remote file by the base64 encoded parts, nil if not exists.
+func (i *interruptReader) readFile(file string) ([]byte, error) {
+	q := common.ShellQuote(file)
+
+	rsp, err := i.executeCmd(fmt.Sprintf("if [ -f %s ]; then echo file; elif [ -e %s ]; then echo other; else echo none; fi", q, q), 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	switch field0(rsp) {
+	case "none":
+		return nil, nil
+	case "file":
+	default:
+		return nil, errors.New("not a regular file")
+	}
+
+	var buf bytes.Buffer
+
+	for skip := 0; ; skip++ {
+		rsp, err := i.executeCmd(fmt.Sprintf(
+			"dd if=%s bs=%d count=1 skip=%d 2>/dev/null | base64 -w 0 && echo", q, 102400, skip), 3*time.Second)
+		if err != nil {
+			return nil, err
+		}
+
+		if rsp == "" {
+			return buf.Bytes(), nil
+		}
+
+		b, err := base64.StdEncoding.DecodeString(rsp)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(b)
+	}
+}
+
+// writeFile writes data to the remote file in place by the uploaded parts.
+func (i *interruptReader) writeFile(file string, data []byte) error {
+	prefix := fmt.Sprintf("/tmp/%s.%s", tsid.Fast().ToString(), filepath.Base(file))
+
+	count, err := i.upParts(bytes.NewReader(data), prefix, nopBar{})
+	if err != nil {
+		return err
+	}
+
+	cmd := ": > " + common.ShellQuote(file) + " && echo saved"
+	if count > 0 {
+		cmd = fmt.Sprintf("cat %s.{1..%d} > %s && echo saved; rm -f %s.{1..%d}", prefix, count, common.ShellQuote(file), prefix, count)
+	}
+
+	rsp, err := i.executeCmd(cmd, 3*time.Second)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(rsp, "saved") {
+		return errors.New(rsp)
+	}
+
+	return nil
+}
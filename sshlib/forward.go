@@ -292,15 +292,20 @@ func (c *Connect) forwarder(local net.Conn, remote net.Conn) {
 	var wg sync.WaitGroup
 	wg.Add(2)
 
+	var lr, rr io.Reader = local, remote
+	if c.LimitForward && c.RateLimit != nil {
+		lr, rr = c.RateLimit(local), c.RateLimit(remote)
+	}
+
 	// Copy local to remote
 	go func() {
-		io.Copy(remote, local)
+		io.Copy(remote, lr)
 		wg.Done()
 	}()
 
 	// Copy remote to local
 	go func() {
-		io.Copy(local, remote)
+		io.Copy(local, rr)
 		wg.Done()
 	}()
 
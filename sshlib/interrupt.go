@@ -211,6 +211,7 @@ Next:
 			"5) .hostinfo     : to show host info\r\n",
 			"6) .exit         : to exit the current bssh connection\r\n",
 			"7) .ps {pid}     : to print process info\r\n",
+			"8) .edit file    : to edit the remote file by local $EDITOR\r\n",
 		)
 	} else if len(cmdFields) == 1 && ss.AnyOf(cmd, ".hostinfo") {
 		if i.hostInfoScript == "" {
@@ -268,6 +269,8 @@ Next:
 		i.directWriter.Write([]byte("exit"))
 	} else if len(cmdFields) == 2 && ss.AnyOf(cmd, ".up") {
 		i.up(cmdFields[1])
+	} else if len(cmdFields) == 2 && ss.AnyOf(cmd, ".edit") {
+		i.edit(cmdFields[1])
 	} else if len(cmdFields) == 2 && ss.AnyOf(cmd, ".dl") {
 		i.dl(cmdFields[1])
 
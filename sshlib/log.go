@@ -2,9 +2,9 @@ package sshlib
 
 import (
 	"bytes"
-	"fmt"
 	"io"
-	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lunixbochs/vtclean"
@@ -12,29 +12,31 @@ import (
 )
 
 type logWriter struct {
-	logfile       *os.File
+	logfile       io.Writer
 	logTimestamp  bool
 	toggleLogging *atomic.Bool
 
 	// keep ansi code on terminal log.
 	logKeepAnsiCode bool
 
-	buf bytes.Buffer
+	// mask the secrets of the log lines.
+	redactor *Redactor
+
+	mu sync.Mutex
+	// buf is the incomplete last line.
+	buf []byte
 }
 
-func NewLogWrite(logfile *os.File, toggleLogging *atomic.Bool, logTimestamp, logKeepAnsiCode bool) io.Writer {
-	w := &logWriter{
+// NewLogWrite creates the terminal log writer of logfile.
+// Close writes the incomplete last line, and closes logfile if it is an io.Closer.
+func NewLogWrite(logfile io.Writer, toggleLogging *atomic.Bool, logTimestamp, logKeepAnsiCode bool, redactor *Redactor) io.WriteCloser {
+	return &logWriter{
 		logfile:         logfile,
 		logTimestamp:    logTimestamp,
 		toggleLogging:   toggleLogging,
 		logKeepAnsiCode: logKeepAnsiCode,
+		redactor:        redactor,
 	}
-
-	if !logKeepAnsiCode {
-		go w.cleanLog()
-	}
-
-	return w
 }
 
 func (l *logWriter) Write(p []byte) (n int, err error) {
@@ -42,45 +44,65 @@ func (l *logWriter) Write(p []byte) (n int, err error) {
 		return len(p), nil
 	}
 
-	if !l.logKeepAnsiCode || l.logTimestamp {
-		return l.buf.Write(p)
+	if !l.buffered() {
+		return l.logfile.Write(p)
 	}
 
-	return l.logfile.Write(p)
-}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-func (l *logWriter) cleanLog() {
-	var preLine []byte
+	data := append(l.buf, p...)
 	for {
-		if l.buf.Len() > 0 {
-			// get line
-			line, err := l.buf.ReadBytes('\n')
-
-			if err == io.EOF {
-				preLine = append(preLine, line...)
-				continue
-			} else {
-				printLine := string(append(preLine, line...))
-
-				if l.logTimestamp {
-					timestamp := time.Now().Format("2006/01/02 15:04:05 ") // yyyy/mm/dd HH:MM:SS
-					printLine = timestamp + printLine
-				}
-
-				// remove ansi code.
-				if !l.logKeepAnsiCode {
-					// NOTE:
-					//     In vtclean.Clean, the beginning of the line is deleted for some reason.
-					//     for that reason, one character add at line head.
-					printLine = "." + printLine
-					printLine = vtclean.Clean(printLine, false)
-				}
-
-				fmt.Fprintf(l.logfile, printLine)
-				preLine = []byte{}
-			}
-		} else {
-			time.Sleep(10 * time.Millisecond)
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
 		}
+
+		l.writeLine(string(data[:i+1]))
+		data = data[i+1:]
+	}
+
+	l.buf = append(l.buf[:0], data...)
+
+	return len(p), nil
+}
+
+// Close writes the incomplete last line, and closes the log file.
+func (l *logWriter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buf) > 0 {
+		l.writeLine(string(l.buf))
+		l.buf = nil
 	}
+
+	if c, ok := l.logfile.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// buffered reports whether the log is written line by line.
+func (l *logWriter) buffered() bool {
+	return !l.logKeepAnsiCode || l.logTimestamp || l.redactor != nil
+}
+
+func (l *logWriter) writeLine(printLine string) {
+	if l.logTimestamp {
+		timestamp := time.Now().Format("2006/01/02 15:04:05 ") // yyyy/mm/dd HH:MM:SS
+		printLine = timestamp + printLine
+	}
+
+	// remove ansi code.
+	if !l.logKeepAnsiCode {
+		// NOTE:
+		//     vtclean.Clean treats the trailing \r as a carriage return, and moves the line end to the head,
+		//     so the line end is trimmed before cleaning.
+		printLine = vtclean.Clean(strings.TrimRight(printLine, "\r\n"), false) + "\n"
+	}
+
+	printLine = l.redactor.Redact(printLine)
+	_, _ = io.WriteString(l.logfile, printLine)
 }
@@ -0,0 +1,125 @@
+package sshlib
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactMask replaces the secrets in the terminal logs and histories.
+const RedactMask = "******"
+
+// builtinRedactPatterns detects the common secrets and tokens.
+// If the pattern has a capturing group, only the group is masked, otherwise the whole match.
+var builtinRedactPatterns = []string{
+	// export TOKEN=xxx, password: xxx, api_key = "xxx", the key ends with the keyword, not like /etc/passwd: or tokens:
+	`(?i)(?:^|[\s"'{(,;])[\w.-]*(?:passw(?:or)?d|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential)["']?\s*[=:]\s*("[^"]*"|'[^']*'|[^\s"',;]+)`,
+	// Authorization: Bearer xxx / Basic xxx
+	`(?i)\bauthorization["']?\s*[=:]\s*(?:bearer|basic)\s+([A-Za-z0-9._~+/=-]{8,})`,
+	// mysql -pxxx, mysqldump --password=xxx is covered by the assignment above
+	`\bmysql(?:dump|admin)?\b[^\n|;]*?\s-p([^\s-]\S*)`,
+	// user:pass@host in the urls
+	`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`,
+	// AWS access key id
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// GitHub tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	// GitLab personal access token
+	`\bglpat-[A-Za-z0-9_-]{20,}\b`,
+	// Slack tokens
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`,
+	// Google API key
+	`\bAIza[0-9A-Za-z_-]{35}\b`,
+	// Stripe keys
+	`\b[sr]k_(?:live|test)_[0-9A-Za-z]{16,}\b`,
+	// OpenAI style secret keys
+	`\bsk-[A-Za-z0-9_-]{20,}\b`,
+	// JSON web token
+	`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`,
+}
+
+// Redactor masks the secrets in the text by the regexp patterns.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates the redactor of the patterns, with the built-in detectors if builtin is true.
+// It returns nil if there is no pattern, and the invalid patterns are returned in errs.
+func NewRedactor(patterns []string, builtin bool) (r *Redactor, errs []error) {
+	if builtin {
+		patterns = append(append([]string(nil), builtinRedactPatterns...), patterns...)
+	}
+
+	r = &Redactor{}
+	for _, p := range patterns {
+		reg, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		r.patterns = append(r.patterns, reg)
+	}
+
+	if len(r.patterns) == 0 {
+		return nil, errs
+	}
+
+	return r, errs
+}
+
+// Redact returns s with the secrets masked, it is safe to call on nil.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, reg := range r.patterns {
+		s = redactAll(reg, s)
+	}
+
+	return s
+}
+
+func redactAll(reg *regexp.Regexp, s string) string {
+	matches := reg.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// mask the first matched group only, e.g. the value of TOKEN=xxx.
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+
+		if start < last || start == end {
+			continue
+		}
+
+		b.WriteString(s[last:start])
+		b.WriteString(RedactMask)
+		last = end
+	}
+
+	b.WriteString(s[last:])
+
+	return b.String()
+}
+
+// passwordPromptReg matches the prompts of no echo input, like "Password:" or "[sudo] password for user:".
+var passwordPromptReg = regexp.MustCompile(`(?i)(?:\b(?:password|passphrase|passcode|pin)|口令|密码)[^\n:：]{0,40}[:：]\s*$`)
+
+// IsPasswordPrompt reports whether the tail of the terminal output is a password prompt,
+// then the next input line is typed without echo and should not be recorded.
+func IsPasswordPrompt(output []byte) bool {
+	if i := strings.LastIndexAny(string(output), "\r\n"); i >= 0 {
+		output = output[i+1:]
+	}
+
+	return len(output) > 0 && passwordPromptReg.Match(output)
+}
@@ -81,6 +81,7 @@ func (c *Connect) ShellInitial(session *ssh.Session, initialInput [][]byte,
 
 	// setup
 	checker := NewInitialPromptReadyChecker()
+	defer c.closeLog()
 	pipeToStdin, ir, err := c.setupShell(session, webPort, hostInfoScript, hostInfoUpdater, checker.Read, processInfoScript)
 	if err != nil {
 		return err
@@ -134,6 +135,7 @@ func (c *Connect) Shell(session *ssh.Session) (err error) {
 	defer term.Restore(fd, state)
 
 	// setup
+	defer c.closeLog()
 	if _, _, err := c.setupShell(session, 0, "", nil, nil, ""); err != nil {
 		return err
 	}
@@ -161,6 +163,7 @@ func (c *Connect) CmdShell(session *ssh.Session, command string) (err error) {
 	defer term.Restore(fd, state)
 
 	// setup
+	defer c.closeLog()
 	if _, _, err := c.setupShell(session, 0, "", nil, nil, ""); err != nil {
 		return err
 	}
@@ -283,6 +286,14 @@ func (c *Connect) ToggleLogging(toggle bool) {
 	c.toggleLogging.Store(toggle)
 }
 
+// closeLog closes the terminal log writer, if any.
+func (c *Connect) closeLog() {
+	if c.logCloser != nil {
+		_ = c.logCloser.Close()
+		c.logCloser = nil
+	}
+}
+
 // logger is logging terminal log to c.logFile
 func (c *Connect) logger(session *ssh.Session) (err error) {
 	logfile, err := os.OpenFile(c.logFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
@@ -290,12 +301,43 @@ func (c *Connect) logger(session *ssh.Session) (err error) {
 		return
 	}
 
-	l := NewLogWrite(logfile, c.toggleLogging, c.logTimestamp, c.LogKeepAnsiCode)
+	if c.LogFormat == LogFormatAsciicast {
+		return c.asciicastLogger(session, logfile)
+	}
+
+	l := NewLogWrite(logfile, c.toggleLogging, c.logTimestamp, c.LogKeepAnsiCode, c.LogRedactor)
+	c.logCloser = l
 	session.Stdout = withLogWriters(session.Stdout, l)
 	session.Stderr = withLogWriters(session.Stderr, l)
 	return nil
 }
 
+// asciicastLogger is recording terminal session to logfile in asciicast v2 format,
+// with the input and output timing and the resize events.
+func (c *Connect) asciicastLogger(session *ssh.Session, logfile *os.File) error {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	w, err := newAsciicastWriter(logfile, c.toggleLogging, width, height, c.LogTitle)
+	if err != nil {
+		return err
+	}
+
+	w.redactor = c.LogRedactor
+	w.watchResize()
+	c.logCloser = w
+
+	session.Stdout = withLogWriters(session.Stdout, w.Output())
+	session.Stderr = withLogWriters(session.Stderr, w.Output())
+	if session.Stdin != nil {
+		session.Stdin = io.TeeReader(session.Stdin, w.Input())
+	}
+
+	return nil
+}
+
 func withLogWriters(writers ...io.Writer) io.Writer {
 	allWriters := make([]io.Writer, 0, len(writers))
 	for _, w := range writers {
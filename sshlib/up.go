@@ -10,45 +10,64 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"time"
 
+	"github.com/bingoohuang/bssh/audit"
 	"github.com/bingoohuang/ngg/tsid"
-	"github.com/cheggaaa/pb/v3"
 )
 
 func (i *interruptReader) up(file string) {
+	event := audit.Start(audit.ActionUpload, i.connect.ServerID)
+	event.Source = file
+
+	var failed error
+	defer func() { event.End(failed) }()
+
 	stat, err := os.Stat(file)
 	if err != nil {
 		log.Printf("stat error: %v", err)
+		failed = err
 		return
 	}
 
 	f, err := os.Open(file)
 	if err != nil {
 		log.Printf("open error: %v", err)
+		failed = err
 		return
 	}
 	defer f.Close()
 
 	prefix := fmt.Sprintf("/tmp/%s.%s", tsid.Fast().ToString(), filepath.Base(file))
+	event.Target, event.Size = prefix, stat.Size()
 	os.Stdout.Write([]byte(fmt.Sprintf("start to upload local %s to remote %s\n",
 		file, prefix)))
 
 	// create bar
-	bar := pb.New(int(stat.Size()))
-	// refresh info every second (default 200ms)
-	bar.SetRefreshRate(time.Second)
-	// force set io.Writer, by default it's os.Stderr
-	bar.SetWriter(os.Stdout)
-	// bar will format numbers as bytes (B, KiB, MiB, etc)
-	bar.Set(pb.Bytes, true)
-	bar.Start()
+	bar := i.connect.newBar(stat.Size())
+
+	count, err := i.upParts(i.connect.rateLimit(f), prefix, bar)
+	bar.Finish(err)
+
+	if err != nil {
+		log.Printf("%v", err)
+		failed = err
+		return
+	}
+
+	t := tsid.Fast().ToString()
+	cmd := fmt.Sprintf("echo open:%s; cat %s.{1..%d} > %s; rm -fr %s.{1..%d}; echo close:%s\r",
+		t, prefix, count, prefix, prefix, count, t)
+	i.directWriter.Write([]byte(cmd))
+	i.notifyC <- NotifyCmd{Type: NotifyTypeTag, Value: t}
+	<-i.notifyRspC
+}
 
+// upParts uploads r by the base64 encoded parts to prefix.1, prefix.2..., returns the number of the parts.
+func (i *interruptReader) upParts(r io.Reader, prefix string, bar ProgressBar) (int, error) {
 	bs := make([]byte, 20480)
 	count := 0
 	for idx := 1; ; idx++ {
-		n, err := f.Read(bs)
-		bs = bs[:n]
+		n, err := r.Read(bs)
 		if err != nil && errors.Is(err, io.EOF) {
 			break
 		}
@@ -56,29 +75,20 @@ func (i *interruptReader) up(file string) {
 
 		count++
 		tmpfile := fmt.Sprintf("%s.%d", prefix, idx)
-		content := base64.StdEncoding.EncodeToString(bs)
+		content := base64.StdEncoding.EncodeToString(bs[:n])
 		t := tsid.Fast().ToString()
 		cmd := fmt.Sprintf("echo open:%s; echo %s | base64 -d > %s ; md5sum %s; echo close:%s\r",
 			t, content, tmpfile, tmpfile, t)
 		i.directWriter.Write([]byte(cmd))
 		i.notifyC <- NotifyCmd{Type: NotifyTypeTag, Value: t}
-		localMd5 := Md5Hash(bs)
+		localMd5 := Md5Hash(bs[:n])
 		rsp := <-i.notifyRspC
 		if field0(rsp) != localMd5 {
-			bar.Finish()
-			log.Printf("write failed")
-			return
+			return count, errors.New("write failed")
 		}
 	}
 
-	bar.Finish()
-
-	t := tsid.Fast().ToString()
-	cmd := fmt.Sprintf("echo open:%s; cat %s.{1..%d} > %s; rm -fr %s.{1..%d}; echo close:%s\r",
-		t, prefix, count, prefix, prefix, count, t)
-	i.directWriter.Write([]byte(cmd))
-	i.notifyC <- NotifyCmd{Type: NotifyTypeTag, Value: t}
-	<-i.notifyRspC
+	return count, nil
 }
 
 func Md5Hash(raw []byte) string {
@@ -0,0 +1,39 @@
+//go:build !windows && !plan9 && !nacl
+// +build !windows,!plan9,!nacl
+
+package sshlib
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// notifyResize calls fn when the terminal is resized, until stop is called.
+func notifyResize(fn func()) (stop func()) {
+	signalchan := make(chan os.Signal, 1)
+	signal.Notify(signalchan, syscall.SIGWINCH)
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signalchan:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(signalchan)
+			close(done)
+		})
+	}
+}
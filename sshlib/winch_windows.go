@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package sshlib
+
+// notifyResize does nothing, there is no resize signal on windows.
+func notifyResize(func()) (stop func()) {
+	return func() {}
+}